@@ -0,0 +1,352 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ArgType is the type of the value of a positional argument declared with [ArgDef].
+type ArgType string
+
+// These are the types supported for positional arguments.
+const (
+	// ArgTypeString accepts any value.  It is the type used when none is specified.
+	ArgTypeString ArgType = "string"
+	// ArgTypeInt accepts an integer.
+	ArgTypeInt ArgType = "int"
+	// ArgTypeFloat accepts a floating-point number.
+	ArgTypeFloat ArgType = "float"
+	// ArgTypeBool accepts a boolean, as parsed by strconv.ParseBool.
+	ArgTypeBool ArgType = "bool"
+	// ArgTypeDuration accepts a duration, as parsed by time.ParseDuration.
+	ArgTypeDuration ArgType = "duration"
+	// ArgTypeFile accepts any value and completes file names.
+	ArgTypeFile ArgType = "file"
+	// ArgTypeDir accepts any value and completes directory names.
+	ArgTypeDir ArgType = "dir"
+	// ArgTypeEnum accepts one of the values listed in ArgDef.Values.
+	ArgTypeEnum ArgType = "enum"
+)
+
+// ArgDef declares a positional argument of a command.
+// The positional arguments of a command are declared in order in Command.ArgDefs.
+type ArgDef struct {
+	// Name is the name of the argument as shown in the usage line and help, e.g. "RELEASE".
+	Name string
+	// Description is a short description of the argument shown in the help.
+	Description string
+	// Type is the type of the value of the argument.  It defaults to ArgTypeString.
+	Type ArgType
+	// Values lists the accepted values of an argument of type ArgTypeEnum.
+	Values []string
+	// Optional indicates the argument may be omitted.
+	// Optional arguments must follow all the required ones.
+	Optional bool
+	// Variadic indicates the argument accepts any number of values.
+	// Only the last argument can be variadic; unless it is also optional,
+	// at least one value is required.
+	Variadic bool
+}
+
+// argType returns the type of the argument, which defaults to ArgTypeString.
+func (a ArgDef) argType() ArgType {
+	if a.Type == "" {
+		return ArgTypeString
+	}
+	return a.Type
+}
+
+// synopsis returns how the argument is shown in the usage line.
+func (a ArgDef) synopsis() string {
+	s := a.Name
+	if a.Variadic {
+		s += "..."
+	}
+	if a.Optional {
+		s = "[" + s + "]"
+	}
+	return s
+}
+
+// parse checks that value is valid for the type of the argument and converts it.
+func (a ArgDef) parse(value string) (interface{}, error) {
+	switch a.argType() {
+	case ArgTypeInt:
+		return strconv.Atoi(value)
+	case ArgTypeFloat:
+		return strconv.ParseFloat(value, 64)
+	case ArgTypeBool:
+		return strconv.ParseBool(value)
+	case ArgTypeDuration:
+		return time.ParseDuration(value)
+	case ArgTypeEnum:
+		for _, v := range a.Values {
+			if v == value {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s", strings.Join(a.Values, ", "))
+	default:
+		return value, nil
+	}
+}
+
+// HasArgDefs checks if the command declares its positional arguments.
+func (c *Command) HasArgDefs() bool {
+	return len(c.ArgDefs) > 0
+}
+
+// ArgDefUsages returns a string containing the usage information
+// for the positional arguments declared in ArgDefs.
+func (c *Command) ArgDefUsages() string {
+	lines := make([]string, 0, len(c.ArgDefs))
+	maxlen := 0
+	for _, a := range c.ArgDefs {
+		line := "  " + a.synopsis()
+		if t := a.argType(); t != ArgTypeString && t != ArgTypeEnum {
+			line += " " + string(t)
+		}
+		if len(line) > maxlen {
+			maxlen = len(line)
+		}
+		lines = append(lines, line)
+	}
+
+	buf := new(bytes.Buffer)
+	for i, a := range c.ArgDefs {
+		usage := a.Description
+		if a.argType() == ArgTypeEnum && len(a.Values) > 0 {
+			usage += " (one of " + strings.Join(a.Values, ", ") + ")"
+		}
+		fmt.Fprintf(buf, "%s   %s\n", rpad(lines[i], maxlen), strings.TrimSpace(usage))
+	}
+	return buf.String()
+}
+
+// argDefsSynopsis returns the declared positional arguments as shown in the usage line.
+func (c *Command) argDefsSynopsis() string {
+	names := make([]string, 0, len(c.ArgDefs))
+	for _, a := range c.ArgDefs {
+		names = append(names, a.synopsis())
+	}
+	return strings.Join(names, " ")
+}
+
+// argDefAt returns the declaration of the positional argument at the specified position.
+func (c *Command) argDefAt(i int) (ArgDef, bool) {
+	if i < len(c.ArgDefs) {
+		return c.ArgDefs[i], true
+	}
+	if n := len(c.ArgDefs); n > 0 && c.ArgDefs[n-1].Variadic {
+		return c.ArgDefs[n-1], true
+	}
+	return ArgDef{}, false
+}
+
+// checkArgDefs validates the declarations of the positional arguments.
+func (c *Command) checkArgDefs() error {
+	optional := false
+	for i, a := range c.ArgDefs {
+		if a.Name == "" {
+			return fmt.Errorf("positional argument %d of %q has no name", i+1, c.CommandPath())
+		}
+		if a.Variadic && i != len(c.ArgDefs)-1 {
+			return fmt.Errorf("positional argument %s of %q is variadic but is not the last one", a.Name, c.CommandPath())
+		}
+		if optional && !a.Optional {
+			return fmt.Errorf("positional argument %s of %q is required but follows an optional one", a.Name, c.CommandPath())
+		}
+		optional = a.Optional
+	}
+	return nil
+}
+
+// validateArgDefs checks the number of arguments and the value of each of
+// them against the declared positional arguments.
+func (c *Command) validateArgDefs(args []string) error {
+	if err := c.checkArgDefs(); err != nil {
+		return err
+	}
+
+	required := 0
+	for _, a := range c.ArgDefs {
+		if !a.Optional {
+			required++
+		}
+	}
+	var countArgs PositionalArgs
+	switch {
+	case c.ArgDefs[len(c.ArgDefs)-1].Variadic:
+		countArgs = MinimumNArgs(required)
+	case required == len(c.ArgDefs):
+		countArgs = ExactArgs(required)
+	default:
+		countArgs = RangeArgs(required, len(c.ArgDefs))
+	}
+	if err := countArgs(c, args); err != nil {
+		return err
+	}
+
+	for i, value := range args {
+		a, _ := c.argDefAt(i)
+		if _, err := a.parse(value); err != nil {
+			return &InvalidArgValueError{Name: a.Name, Value: value, Err: unwrapNumError(err)}
+		}
+	}
+	return nil
+}
+
+// unwrapNumError removes the name of the strconv function from a parsing error.
+func unwrapNumError(err error) error {
+	if numErr, ok := err.(*strconv.NumError); ok {
+		return numErr.Err
+	}
+	return err
+}
+
+// GetArgs returns the values of the declared positional argument with the given name.
+// It is mostly useful for variadic arguments.  An omitted optional argument has no value.
+func (c *Command) GetArgs(name string) ([]string, error) {
+	for i, a := range c.ArgDefs {
+		if a.Name != name {
+			continue
+		}
+		switch {
+		case i >= len(c.argValues):
+			return []string{}, nil
+		case a.Variadic:
+			return c.argValues[i:], nil
+		default:
+			return c.argValues[i : i+1], nil
+		}
+	}
+	return nil, fmt.Errorf("positional argument %s is not declared", name)
+}
+
+// getArg returns the parsed value of the declared positional argument with the given name,
+// which must be of type argType.  An omitted optional argument returns a nil value.
+func (c *Command) getArg(name string, argType ArgType) (interface{}, error) {
+	values, err := c.GetArgs(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range c.ArgDefs {
+		if a.Name != name {
+			continue
+		}
+		if t := a.argType(); t != argType {
+			return nil, fmt.Errorf("trying to get %s value of positional argument of type %s", argType, t)
+		}
+		if a.Variadic {
+			return nil, fmt.Errorf("positional argument %s is variadic, use GetArgs", name)
+		}
+		if len(values) == 0 {
+			return nil, nil
+		}
+		return a.parse(values[0])
+	}
+	return nil, nil
+}
+
+// GetArg returns the value of the declared positional argument with the given name.
+// It can be used for arguments of any type, except variadic ones.
+func (c *Command) GetArg(name string) (string, error) {
+	values, err := c.GetArgs(name)
+	if err != nil {
+		return "", err
+	}
+	for _, a := range c.ArgDefs {
+		if a.Name == name && a.Variadic {
+			return "", fmt.Errorf("positional argument %s is variadic, use GetArgs", name)
+		}
+	}
+	if len(values) == 0 {
+		return "", nil
+	}
+	return values[0], nil
+}
+
+// GetArgInt returns the value of the declared positional argument of type
+// ArgTypeInt with the given name.
+func (c *Command) GetArgInt(name string) (int, error) {
+	v, err := c.getArg(name, ArgTypeInt)
+	if err != nil || v == nil {
+		return 0, err
+	}
+	return v.(int), nil
+}
+
+// GetArgFloat64 returns the value of the declared positional argument of type
+// ArgTypeFloat with the given name.
+func (c *Command) GetArgFloat64(name string) (float64, error) {
+	v, err := c.getArg(name, ArgTypeFloat)
+	if err != nil || v == nil {
+		return 0, err
+	}
+	return v.(float64), nil
+}
+
+// GetArgBool returns the value of the declared positional argument of type
+// ArgTypeBool with the given name.
+func (c *Command) GetArgBool(name string) (bool, error) {
+	v, err := c.getArg(name, ArgTypeBool)
+	if err != nil || v == nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+// GetArgDuration returns the value of the declared positional argument of type
+// ArgTypeDuration with the given name.
+func (c *Command) GetArgDuration(name string) (time.Duration, error) {
+	v, err := c.getArg(name, ArgTypeDuration)
+	if err != nil || v == nil {
+		return 0, err
+	}
+	return v.(time.Duration), nil
+}
+
+// completeArgDefs completes the positional arguments based on their declaration.
+func completeArgDefs(cmd *Command, args []string, toComplete string) ([]Completion, ShellCompDirective) {
+	a, ok := cmd.argDefAt(len(args))
+	if !ok {
+		return nil, ShellCompDirectiveNoFileComp
+	}
+
+	var values []string
+	switch a.argType() {
+	case ArgTypeFile:
+		return nil, ShellCompDirectiveDefault
+	case ArgTypeDir:
+		return nil, ShellCompDirectiveFilterDirs
+	case ArgTypeEnum:
+		values = a.Values
+	case ArgTypeBool:
+		values = []string{"true", "false"}
+	}
+
+	var completions []Completion
+	for _, v := range values {
+		if strings.HasPrefix(v, toComplete) {
+			completions = append(completions, v)
+		}
+	}
+	return completions, ShellCompDirectiveNoFileComp
+}
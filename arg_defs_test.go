@@ -0,0 +1,215 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func getArgDefsCmd() *Command {
+	return &Command{
+		Use: "deploy",
+		ArgDefs: []ArgDef{
+			{Name: "ENV", Description: "the environment", Type: ArgTypeEnum, Values: []string{"dev", "prod"}},
+			{Name: "REPLICAS", Description: "the number of replicas", Type: ArgTypeInt},
+			{Name: "TIMEOUT", Description: "how long to wait", Type: ArgTypeDuration, Optional: true},
+			{Name: "FILES", Description: "the manifests", Type: ArgTypeFile, Optional: true, Variadic: true},
+		},
+		Run: emptyRun,
+	}
+}
+
+func TestArgDefsValidation(t *testing.T) {
+	tests := []struct {
+		args          []string
+		expectedError string
+	}{
+		{args: []string{"dev", "3"}},
+		{args: []string{"dev", "3", "5s", "a.yaml", "b.yaml"}},
+		{args: []string{"dev"}, expectedError: "requires at least 2 arg(s), only received 1"},
+		{args: []string{"test", "3"}, expectedError: `invalid value "test" for argument ENV: must be one of dev, prod`},
+		{args: []string{"dev", "three"}, expectedError: `invalid value "three" for argument REPLICAS: invalid syntax`},
+		{args: []string{"dev", "3", "soon"}, expectedError: `invalid value "soon" for argument TIMEOUT: time: invalid duration "soon"`},
+	}
+	for _, tc := range tests {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			_, err := executeCommand(getArgDefsCmd(), tc.args...)
+			if tc.expectedError == "" {
+				assertNoErr(t, err)
+				return
+			}
+			if err == nil || err.Error() != tc.expectedError {
+				t.Errorf("Expected error %q, got: %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestArgDefsCount(t *testing.T) {
+	c := &Command{
+		Use:     "c",
+		ArgDefs: []ArgDef{{Name: "A"}, {Name: "B", Optional: true}},
+		Run:     emptyRun,
+	}
+	_, err := executeCommand(c, "a", "b", "c")
+	if err == nil || err.Error() != "accepts between 1 and 2 arg(s), received 3" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	c.ArgDefs[1].Optional = false
+	_, err = executeCommand(c, "a")
+	if err == nil || err.Error() != "accepts 2 arg(s), received 1" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestArgDefsWithArgs(t *testing.T) {
+	c := &Command{
+		Use:     "c",
+		Args:    NoDuplicateArgs,
+		ArgDefs: []ArgDef{{Name: "NAMES", Variadic: true}},
+		Run:     emptyRun,
+	}
+	_, err := executeCommand(c, "a", "a")
+	if err == nil || !strings.Contains(err.Error(), "duplicate argument") {
+		t.Errorf("Expected Args to also be validated, got: %v", err)
+	}
+}
+
+func TestArgDefsInvalidDeclarations(t *testing.T) {
+	tests := []struct {
+		name          string
+		argDefs       []ArgDef
+		expectedError string
+	}{
+		{
+			name:          "variadic not last",
+			argDefs:       []ArgDef{{Name: "A", Variadic: true}, {Name: "B"}},
+			expectedError: `positional argument A of "c" is variadic but is not the last one`,
+		},
+		{
+			name:          "required after optional",
+			argDefs:       []ArgDef{{Name: "A", Optional: true}, {Name: "B"}},
+			expectedError: `positional argument B of "c" is required but follows an optional one`,
+		},
+		{
+			name:          "no name",
+			argDefs:       []ArgDef{{Description: "nameless"}},
+			expectedError: `positional argument 1 of "c" has no name`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Command{Use: "c", ArgDefs: tc.argDefs, Run: emptyRun}
+			_, err := executeCommand(c, "a", "b")
+			if err == nil || err.Error() != tc.expectedError {
+				t.Errorf("Expected error %q, got: %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestArgDefsAccessors(t *testing.T) {
+	c := getArgDefsCmd()
+	var env string
+	var replicas int
+	var timeout time.Duration
+	var files []string
+	c.Run = func(cmd *Command, args []string) {
+		var err error
+		env, err = cmd.GetArg("ENV")
+		assertNoErr(t, err)
+		replicas, err = cmd.GetArgInt("REPLICAS")
+		assertNoErr(t, err)
+		timeout, err = cmd.GetArgDuration("TIMEOUT")
+		assertNoErr(t, err)
+		files, err = cmd.GetArgs("FILES")
+		assertNoErr(t, err)
+
+		if _, err := cmd.GetArgInt("ENV"); err == nil {
+			t.Error("Expected an error when getting the wrong type")
+		}
+		if _, err := cmd.GetArg("FILES"); err == nil {
+			t.Error("Expected an error when getting a single value of a variadic argument")
+		}
+		if _, err := cmd.GetArg("UNKNOWN"); err == nil {
+			t.Error("Expected an error for an undeclared argument")
+		}
+	}
+
+	_, err := executeCommand(c, "prod", "--", "3", "1m", "a.yaml", "b.yaml")
+	assertNoErr(t, err)
+	if env != "prod" || replicas != 3 || timeout != time.Minute || strings.Join(files, ",") != "a.yaml,b.yaml" {
+		t.Errorf("Unexpected values: %q %d %v %v", env, replicas, timeout, files)
+	}
+
+	// Omitted optional arguments have their zero value
+	_, err = executeCommand(c, "dev", "1")
+	assertNoErr(t, err)
+	if timeout != 0 || len(files) != 0 {
+		t.Errorf("Unexpected values: %v %v", timeout, files)
+	}
+}
+
+func TestArgDefsUsage(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	c := getArgDefsCmd()
+	rootCmd.AddCommand(c)
+
+	output, err := executeCommand(rootCmd, "deploy", "--help")
+	assertNoErr(t, err)
+
+	checkStringContains(t, output, "root deploy ENV REPLICAS [TIMEOUT] [FILES...] [flags]")
+	expected := `Arguments:
+  ENV                  the environment (one of dev, prod)
+  REPLICAS int         the number of replicas
+  [TIMEOUT] duration   how long to wait
+  [FILES...] file      the manifests
+
+Flags:`
+	checkStringContains(t, output, expected)
+
+	// An explicit usage line is kept as is
+	c.Use = "deploy ENV REPLICAS"
+	if c.UseLine() != "root deploy ENV REPLICAS [flags]" {
+		t.Errorf("Unexpected usage line: %q", c.UseLine())
+	}
+}
+
+func TestArgDefsCompletion(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(getArgDefsCmd())
+
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{args: []string{"deploy", ""}, expected: []string{"dev", "prod", ":4"}},
+		{args: []string{"deploy", "p"}, expected: []string{"prod", ":4"}},
+		{args: []string{"deploy", "dev", ""}, expected: []string{":4"}},
+		{args: []string{"deploy", "dev", "3", "5s", ""}, expected: []string{":0"}},
+		{args: []string{"deploy", "dev", "3", "5s", "a.yaml", ""}, expected: []string{":0"}},
+	}
+	for _, tc := range tests {
+		output, err := executeCommand(rootCmd, append([]string{ShellCompNoDescRequestCmd}, tc.args...)...)
+		assertNoErr(t, err)
+		expected := strings.Join(tc.expected, "\n") + "\n"
+		if !strings.HasPrefix(output, expected) {
+			t.Errorf("%v: expected: %q, got: %q", tc.args, expected, output)
+		}
+	}
+}
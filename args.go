@@ -15,7 +15,6 @@
 package cobra
 
 import (
-	"fmt"
 	"strings"
 )
 
@@ -33,7 +32,7 @@ func legacyArgs(cmd *Command, args []string) error {
 
 	// root command with subcommands, do subcommand checking.
 	if !cmd.HasParent() && len(args) > 0 {
-		return fmt.Errorf("unknown command %q for %q%s", args[0], cmd.CommandPath(), cmd.findSuggestions(args[0]))
+		return &UnknownCommandError{Arg: args[0], CommandPath: cmd.CommandPath(), Suggestions: cmd.findSuggestions(args[0])}
 	}
 	return nil
 }
@@ -41,7 +40,7 @@ func legacyArgs(cmd *Command, args []string) error {
 // NoArgs returns an error if any args are included.
 func NoArgs(cmd *Command, args []string) error {
 	if len(args) > 0 {
-		return fmt.Errorf("unknown command %q for %q", args[0], cmd.CommandPath())
+		return &UnknownCommandError{Arg: args[0], CommandPath: cmd.CommandPath()}
 	}
 	return nil
 }
@@ -58,7 +57,7 @@ func OnlyValidArgs(cmd *Command, args []string) error {
 		}
 		for _, v := range args {
 			if !stringInSlice(v, validArgs) {
-				return fmt.Errorf("invalid argument %q for %q%s", v, cmd.CommandPath(), cmd.findSuggestions(args[0]))
+				return &InvalidArgError{Arg: v, CommandPath: cmd.CommandPath(), Suggestions: cmd.findSuggestions(args[0])}
 			}
 		}
 	}
@@ -70,7 +69,7 @@ func NoDuplicateArgs(cmd *Command, args []string) error {
 	seen := make(map[string]struct{}, len(args))
 	for _, arg := range args {
 		if _, ok := seen[arg]; ok {
-			return fmt.Errorf("duplicate argument %q for %q", arg, cmd.CommandPath())
+			return &DuplicateArgError{Arg: arg, CommandPath: cmd.CommandPath()}
 		}
 		seen[arg] = struct{}{}
 	}
@@ -87,7 +86,7 @@ func ArbitraryArgs(cmd *Command, args []string) error {
 func MinimumNArgs(n int) PositionalArgs {
 	return func(cmd *Command, args []string) error {
 		if len(args) < n {
-			return fmt.Errorf("requires at least %d arg(s), only received %d", n, len(args))
+			return &InvalidArgCountError{Min: n, Max: -1, Received: len(args)}
 		}
 		return nil
 	}
@@ -97,7 +96,7 @@ func MinimumNArgs(n int) PositionalArgs {
 func MaximumNArgs(n int) PositionalArgs {
 	return func(cmd *Command, args []string) error {
 		if len(args) > n {
-			return fmt.Errorf("accepts at most %d arg(s), received %d", n, len(args))
+			return &InvalidArgCountError{Min: -1, Max: n, Received: len(args)}
 		}
 		return nil
 	}
@@ -107,7 +106,7 @@ func MaximumNArgs(n int) PositionalArgs {
 func ExactArgs(n int) PositionalArgs {
 	return func(cmd *Command, args []string) error {
 		if len(args) != n {
-			return fmt.Errorf("accepts %d arg(s), received %d", n, len(args))
+			return &InvalidArgCountError{Min: n, Max: n, Received: len(args)}
 		}
 		return nil
 	}
@@ -117,7 +116,7 @@ func ExactArgs(n int) PositionalArgs {
 func RangeArgs(min int, max int) PositionalArgs {
 	return func(cmd *Command, args []string) error {
 		if len(args) < min || len(args) > max {
-			return fmt.Errorf("accepts between %d and %d arg(s), received %d", min, max, len(args))
+			return &InvalidArgCountError{Min: min, Max: max, Received: len(args)}
 		}
 		return nil
 	}
@@ -24,6 +24,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"text/template"
 	"time"
 	"unicode"
@@ -84,6 +85,7 @@ var MousetrapDisplayDuration = 5 * time.Second
 // template generation.
 func AddTemplateFunc(name string, tmplFunc interface{}) {
 	templateFuncs[name] = tmplFunc
+	atomic.AddUint64(&packageOptions.templateFuncsVersion, 1)
 }
 
 // AddTemplateFuncs adds multiple template functions that are available to Usage and
@@ -92,6 +94,7 @@ func AddTemplateFuncs(tmplFuncs template.FuncMap) {
 	for k, v := range tmplFuncs {
 		templateFuncs[k] = v
 	}
+	atomic.AddUint64(&packageOptions.templateFuncsVersion, 1)
 }
 
 // OnInitialize sets the passed functions to be run when each command's
@@ -176,16 +179,43 @@ func rpad(s string, padding int) string {
 	return fmt.Sprintf(formattedString, s)
 }
 
+// tmpl returns a tmplFunc executing text with the template functions of the command
+// it is executed with.  The template is parsed when it is first executed.
 func tmpl(text string) *tmplFunc {
-	return &tmplFunc{
-		tmpl: text,
-		fn: func(w io.Writer, data interface{}) error {
-			t := template.New("top")
-			t.Funcs(templateFuncs)
-			template.Must(t.Parse(text))
-			return t.Execute(w, data)
-		},
+	t := &tmplFunc{tmpl: text}
+	t.fn = func(w io.Writer, data interface{}) error {
+		o := packageOptions
+		if c, ok := data.(*Command); ok {
+			o = c.opts()
+		}
+		parsed, err := t.parse(o)
+		if err != nil {
+			return err
+		}
+		return parsed.Execute(w, data)
+	}
+	return t
+}
+
+// parse returns the template parsed with the template functions of o.  The parsed
+// template is kept until it is needed with other options, or template functions are
+// added to o.
+func (t *tmplFunc) parse(o *Options) (*template.Template, error) {
+	version := atomic.LoadUint64(&o.templateFuncsVersion)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.parsed != nil && t.opts == o && t.funcsVersion == version {
+		return t.parsed, nil
+	}
+
+	parsed, err := template.New("top").Funcs(o.funcs()).Parse(t.tmpl)
+	if err != nil {
+		return nil, err
 	}
+	t.parsed = parsed
+	t.opts = o
+	t.funcsVersion = version
+	return parsed, nil
 }
 
 // ld compares two strings and returns the levenshtein distance between them.
@@ -26,6 +26,8 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"text/template"
 
 	flag "github.com/spf13/pflag"
 )
@@ -92,6 +94,12 @@ type Command struct {
 	// Expected arguments
 	Args PositionalArgs
 
+	// ArgDefs declares the positional arguments of the command, in order.
+	// When set, the number and the values of the arguments are validated against
+	// the declarations, in addition to Args, and the declarations are used for
+	// the usage line, the help, the documentation and shell completion.
+	ArgDefs []ArgDef
+
 	// ArgAliases is List of aliases for ValidArgs.
 	// These are not suggested to the user in the shell completion,
 	// but accepted if entered manually.
@@ -104,6 +112,11 @@ type Command struct {
 	// Deprecated defines, if this command is deprecated and should print this string when used.
 	Deprecated string
 
+	// Confirm defines, if this command requires confirmation, the question asked to the user
+	// before running it, e.g. "Delete all the releases?".  The command gets a --yes/-y flag
+	// to skip the question, which is required when the input is not a terminal.
+	Confirm string
+
 	// Annotations are key/value pairs that can be used by applications to identify or
 	// group commands or set special options.
 	Annotations map[string]string
@@ -114,6 +127,14 @@ type Command struct {
 	// command does not define one.
 	Version string
 
+	// EnvPrefix enables populating flags from environment variables. When set, every flag
+	// of this command and of its subcommands which is not set on the command-line takes
+	// its value from the environment variable <EnvPrefix>_<FLAG>, where the name is in
+	// upper case with all non-ASCII-alphanumeric characters replaced by `_`.
+	// A subcommand may define its own EnvPrefix to override the one of its parents.
+	// See also BindFlagEnv and MarkFlagNoEnv.
+	EnvPrefix string
+
 	// The *Run functions are executed in the following order:
 	//   * PersistentPreRun()
 	//   * PreRun()
@@ -123,6 +144,8 @@ type Command struct {
 	// All functions get the same args, the arguments after the command name.
 	// The *PreRun and *PostRun functions will only be executed if the Run function of the current
 	// command has been declared.
+	// The *PostRun functions are skipped if RunE returns an error, unless the execution has been
+	// interrupted by a signal (see ExecuteWithSignals).
 	//
 	// PersistentPreRun: children of this command will inherit and execute.
 	PersistentPreRun func(cmd *Command, args []string)
@@ -145,9 +168,24 @@ type Command struct {
 	// PersistentPostRunE: PersistentPostRun but returns an error.
 	PersistentPostRunE func(cmd *Command, args []string) error
 
+	// Finally: run after the command, even if one of its *Run functions returned an error
+	// or panicked.  It receives the error of the execution, if any, and returns the error
+	// to report: return err unchanged, wrap it or replace it.
+	// On a panic, err is nil and the returned error is ignored: the panic goes on once
+	// all the Finally hooks have run.
+	// Children of this command will not inherit.
+	Finally func(cmd *Command, args []string, err error) error
+	// PersistentFinally: Finally but children of this command will also execute it.
+	// All the PersistentFinally functions are executed after Finally, from the executed
+	// command to the root, whatever the value of EnableTraverseRunHooks.
+	PersistentFinally func(cmd *Command, args []string, err error) error
+
 	// groups for subcommands
 	commandgroups []*Group
 
+	// argValues are the positional arguments of the command being executed.
+	argValues []string
+
 	// args is actual args parsed from flags.
 	args []string
 	// flagErrorBuf contains all error messages from pflag.
@@ -175,6 +213,10 @@ type Command struct {
 	// flagErrorFunc is func defined by user and it's called when the parsing of
 	// flags returns an error.
 	flagErrorFunc func(*Command, error) error
+	// exitFunc is func defined by user and it's called to exit the program.
+	exitFunc func(int)
+	// middlewares wrap the execution of the command and its children.
+	middlewares []Middleware
 	// helpTemplate is help template defined by user.
 	helpTemplate *tmplFunc
 	// helpFunc is help func defined by user.
@@ -188,6 +230,20 @@ type Command struct {
 	// completionCommandGroupID is the group id for the completion command
 	completionCommandGroupID string
 
+	// options configure the command and its children instead of the package-level configuration.
+	options *Options
+
+	// configLoader provides flag values from a configuration defined by the user.
+	configLoader ConfigLoader
+
+	// userAliases maps the name of the user aliases to their expansion.
+	userAliases map[string]string
+
+	// argCompletionFuncs are the completion functions of the positional arguments, by index.
+	argCompletionFuncs map[int]CompletionFunc
+	// restArgsCompletionFunc completes the positional arguments without a function in argCompletionFuncs.
+	restArgsCompletionFunc CompletionFunc
+
 	// versionTemplate is the version template defined by user.
 	versionTemplate *tmplFunc
 
@@ -215,6 +271,16 @@ type Command struct {
 		called bool
 	}
 
+	// autoAdded is true for the commands added by cobra during the execution,
+	// such as the default help and completion commands.
+	autoAdded bool
+
+	// completedFlag is the flag whose value is being completed, if any.
+	completedFlag *flag.Flag
+
+	// lazyBuild builds the command replacing this stub, see AddLazyCommand.
+	lazyBuild func() *Command
+
 	ctx context.Context
 
 	// commands is the list of commands supported by this program.
@@ -242,6 +308,10 @@ type Command struct {
 	// If this is true all flags will be passed to the command as arguments.
 	DisableFlagParsing bool
 
+	// PromptMissing prompts the user for the values of the required flags and positional
+	// arguments missing from the command-line, instead of failing, when the input is a terminal.
+	PromptMissing bool
+
 	// DisableAutoGenTag defines, if gen tag ("Auto generated by spf13/cobra...")
 	// will be printed by generating docs for this command.
 	DisableAutoGenTag bool
@@ -323,6 +393,18 @@ func (c *Command) SetUsageTemplate(s string) {
 	c.usageTemplate = tmpl(s)
 }
 
+// SetUsageTemplateE is the same as SetUsageTemplate, but parses the template with the
+// template functions of the command, and returns an error if it is invalid, in which
+// case the usage template is left unchanged.
+func (c *Command) SetUsageTemplateE(s string) error {
+	t, err := c.parseTemplate(s)
+	if err != nil {
+		return err
+	}
+	c.usageTemplate = t
+	return nil
+}
+
 // SetFlagErrorFunc sets a function to generate an error when flag parsing
 // fails.
 func (c *Command) SetFlagErrorFunc(f func(*Command, error) error) {
@@ -363,6 +445,18 @@ func (c *Command) SetHelpTemplate(s string) {
 	c.helpTemplate = tmpl(s)
 }
 
+// SetHelpTemplateE is the same as SetHelpTemplate, but parses the template with the
+// template functions of the command, and returns an error if it is invalid, in which
+// case the help template is left unchanged.
+func (c *Command) SetHelpTemplateE(s string) error {
+	t, err := c.parseTemplate(s)
+	if err != nil {
+		return err
+	}
+	c.helpTemplate = t
+	return nil
+}
+
 // SetVersionTemplate sets version template to be used. Application can use it to set custom template.
 func (c *Command) SetVersionTemplate(s string) {
 	if s == "" {
@@ -372,6 +466,56 @@ func (c *Command) SetVersionTemplate(s string) {
 	c.versionTemplate = tmpl(s)
 }
 
+// SetVersionTemplateE is the same as SetVersionTemplate, but parses the template with the
+// template functions of the command, and returns an error if it is invalid, in which
+// case the version template is left unchanged.
+func (c *Command) SetVersionTemplateE(s string) error {
+	t, err := c.parseTemplate(s)
+	if err != nil {
+		return err
+	}
+	c.versionTemplate = t
+	return nil
+}
+
+// parseTemplate returns the template s parsed with the template functions of c,
+// or nil if s is empty.
+func (c *Command) parseTemplate(s string) (*tmplFunc, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t := tmpl(s)
+	if _, err := t.parse(c.opts()); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ValidateTemplates parses the usage, help and version templates set on c and its
+// subcommands with their template functions, and returns the first error found.
+// The templates are otherwise only parsed when they are first used, so call it from
+// a unit test of the program to detect invalid templates.
+func (c *Command) ValidateTemplates() error {
+	o := c.opts()
+	for _, t := range []struct {
+		kind string
+		tmpl *tmplFunc
+	}{{"usage", c.usageTemplate}, {"help", c.helpTemplate}, {"version", c.versionTemplate}} {
+		if t.tmpl == nil {
+			continue
+		}
+		if _, err := t.tmpl.parse(o); err != nil {
+			return fmt.Errorf("invalid %s template of %q: %v", t.kind, c.CommandPath(), err)
+		}
+	}
+	for _, sub := range c.commands {
+		if err := sub.ValidateTemplates(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SetErrPrefix sets error message prefix to be used. Application can use it to set custom prefix.
 func (c *Command) SetErrPrefix(s string) {
 	c.errPrefix = s
@@ -772,37 +916,31 @@ func (c *Command) Find(args []string) (*Command, []string, error) {
 	}
 
 	commandFound, a := innerfind(c, args)
-	if commandFound.Args == nil {
+	if commandFound.Args == nil && !commandFound.HasArgDefs() {
 		return commandFound, a, legacyArgs(commandFound, stripFlags(a, commandFound))
 	}
 	return commandFound, a, nil
 }
 
-func (c *Command) findSuggestions(arg string) string {
+func (c *Command) findSuggestions(arg string) []string {
 	if c.DisableSuggestions {
-		return ""
+		return nil
 	}
 	if c.SuggestionsMinimumDistance <= 0 {
 		c.SuggestionsMinimumDistance = 2
 	}
-	var sb strings.Builder
-	if suggestions := c.SuggestionsFor(arg); len(suggestions) > 0 {
-		sb.WriteString("\n\nDid you mean this?\n")
-		for _, s := range suggestions {
-			_, _ = fmt.Fprintf(&sb, "\t%v\n", s)
-		}
-	}
-	return sb.String()
+	return c.SuggestionsFor(arg)
 }
 
 func (c *Command) findNext(next string) *Command {
 	matches := make([]*Command, 0)
 	for _, cmd := range c.commands {
-		if commandNameMatches(cmd.Name(), next) || cmd.HasAlias(next) {
+		if c.commandNameMatches(cmd.Name(), next) || cmd.HasAlias(next) {
+			cmd = c.buildLazyCommand(cmd)
 			cmd.commandCalledAs.name = next
 			return cmd
 		}
-		if EnablePrefixMatching && cmd.hasNameOrAliasPrefix(next) {
+		if c.opts().prefixMatching() && cmd.hasNameOrAliasPrefix(next) {
 			matches = append(matches, cmd)
 		}
 	}
@@ -810,7 +948,7 @@ func (c *Command) findNext(next string) *Command {
 	if len(matches) == 1 {
 		// Temporarily disable gosec G602, which produces a false positive.
 		// See https://github.com/securego/gosec/issues/1005.
-		return matches[0] // #nosec G602
+		return c.buildLazyCommand(matches[0]) // #nosec G602
 	}
 
 	return nil
@@ -915,10 +1053,11 @@ func (c *Command) execute(a []string) (err error) {
 	// overriding
 	c.InitDefaultHelpFlag()
 	c.InitDefaultVersionFlag()
+	c.InitDefaultConfirmFlag()
 
 	err = c.ParseFlags(a)
 	if err != nil {
-		return c.FlagErrorFunc()(c, err)
+		return c.flagError(err)
 	}
 
 	// If help is called, regardless of other flags, return we want help.
@@ -956,6 +1095,15 @@ func (c *Command) execute(a []string) (err error) {
 		return flag.ErrHelp
 	}
 
+	// Populate flags not set on the command-line from the environment,
+	// then from the configuration
+	if err := c.applyFlagEnv(); err != nil {
+		return c.flagError(err)
+	}
+	if err := c.applyFlagConfig(); err != nil {
+		return c.flagError(err)
+	}
+
 	c.preRun()
 
 	defer c.postRun()
@@ -965,13 +1113,32 @@ func (c *Command) execute(a []string) (err error) {
 		argWoFlags = a
 	}
 
+	defer func() {
+		err = c.runFinallyHooks(argWoFlags, err)
+	}()
+
+	if c.PromptMissing {
+		if argWoFlags, err = c.promptMissing(argWoFlags); err != nil {
+			return err
+		}
+	}
+	c.argValues = argWoFlags
+
+	return c.runMiddlewares(argWoFlags, func() error {
+		return c.runHooks(argWoFlags)
+	})
+}
+
+// runHooks validates the arguments and the flags of the command, then runs its *Run functions.
+func (c *Command) runHooks(argWoFlags []string) error {
 	if err := c.ValidateArgs(argWoFlags); err != nil {
-		return err
+		return argsError(err)
 	}
 
+	traverseRunHooks := c.opts().traverseRunHooks()
 	parents := make([]*Command, 0, 5)
 	for p := c; p != nil; p = p.Parent() {
-		if EnableTraverseRunHooks {
+		if traverseRunHooks {
 			// When EnableTraverseRunHooks is set:
 			// - Execute all persistent pre-runs from the root parent till this command.
 			// - Execute all persistent post-runs from this command till the root parent.
@@ -986,12 +1153,12 @@ func (c *Command) execute(a []string) (err error) {
 			if err := p.PersistentPreRunE(c, argWoFlags); err != nil {
 				return err
 			}
-			if !EnableTraverseRunHooks {
+			if !traverseRunHooks {
 				break
 			}
 		} else if p.PersistentPreRun != nil {
 			p.PersistentPreRun(c, argWoFlags)
-			if !EnableTraverseRunHooks {
+			if !traverseRunHooks {
 				break
 			}
 		}
@@ -1005,53 +1172,81 @@ func (c *Command) execute(a []string) (err error) {
 	}
 
 	if err := c.ValidateRequiredFlags(); err != nil {
-		return err
+		return usageError(err)
 	}
 	if err := c.ValidateFlagGroups(); err != nil {
+		return usageError(err)
+	}
+	if err := c.confirm(); err != nil {
 		return err
 	}
 
 	if c.RunE != nil {
 		if err := c.RunE(c, argWoFlags); err != nil {
+			// A command interrupted by a signal still gets to clean up.
+			if interruptedBySignal(c.Context()) {
+				_ = c.runPostRunHooks(argWoFlags)
+			}
 			return err
 		}
 	} else {
 		c.Run(c, argWoFlags)
 	}
+
+	return c.runPostRunHooks(argWoFlags)
+}
+
+// runFinallyHooks runs the Finally hook of the command and the PersistentFinally hooks
+// of the command and its parents, and returns the resulting error.
+func (c *Command) runFinallyHooks(args []string, err error) error {
+	if c.Finally != nil {
+		err = c.Finally(c, args, err)
+	}
+	for p := c; p != nil; p = p.Parent() {
+		if p.PersistentFinally != nil {
+			err = p.PersistentFinally(c, args, err)
+		}
+	}
+	return err
+}
+
+// runPostRunHooks runs the PostRun hook of the command and the PersistentPostRun
+// hooks of its parents.
+func (c *Command) runPostRunHooks(args []string) error {
+	traverseRunHooks := c.opts().traverseRunHooks()
 	if c.PostRunE != nil {
-		if err := c.PostRunE(c, argWoFlags); err != nil {
+		if err := c.PostRunE(c, args); err != nil {
 			return err
 		}
 	} else if c.PostRun != nil {
-		c.PostRun(c, argWoFlags)
+		c.PostRun(c, args)
 	}
 	for p := c; p != nil; p = p.Parent() {
 		if p.PersistentPostRunE != nil {
-			if err := p.PersistentPostRunE(c, argWoFlags); err != nil {
+			if err := p.PersistentPostRunE(c, args); err != nil {
 				return err
 			}
-			if !EnableTraverseRunHooks {
+			if !traverseRunHooks {
 				break
 			}
 		} else if p.PersistentPostRun != nil {
-			p.PersistentPostRun(c, argWoFlags)
-			if !EnableTraverseRunHooks {
+			p.PersistentPostRun(c, args)
+			if !traverseRunHooks {
 				break
 			}
 		}
 	}
-
 	return nil
 }
 
 func (c *Command) preRun() {
-	for _, x := range initializers {
+	for _, x := range c.opts().initializeFuncs() {
 		x()
 	}
 }
 
 func (c *Command) postRun() {
-	for _, x := range finalizers {
+	for _, x := range c.opts().finalizeFuncs() {
 		x()
 	}
 }
@@ -1099,6 +1294,9 @@ func (c *Command) ExecuteC() (cmd *Command, err error) {
 	// initialize help at the last point to allow for user overriding
 	c.InitDefaultHelpCmd()
 
+	// initialize the commands of the user aliases
+	c.initUserAliasCmds()
+
 	args := c.args
 
 	// Workaround FAIL with "go test -v" or "cobra.test -test.v", see #155
@@ -1109,6 +1307,9 @@ func (c *Command) ExecuteC() (cmd *Command, err error) {
 	// initialize the __complete command to be used for shell completion
 	c.initCompleteCmd(args)
 
+	// initialize the __completeServer command to be used by editors and terminals
+	c.initCompleteServerCmd(args)
+
 	// initialize the default completion command
 	c.InitDefaultCompletionCmd(args...)
 
@@ -1116,6 +1317,14 @@ func (c *Command) ExecuteC() (cmd *Command, err error) {
 	// are properly created also
 	c.checkCommandGroups()
 
+	args, err = c.expandUserAliases(args)
+	if err != nil {
+		if !c.SilenceErrors {
+			c.PrintErrln(c.ErrPrefix(), err.Error())
+		}
+		return c, err
+	}
+
 	var flags []string
 	if c.TraverseChildren {
 		cmd, flags, err = c.Traverse(args)
@@ -1127,6 +1336,7 @@ func (c *Command) ExecuteC() (cmd *Command, err error) {
 		if cmd != nil {
 			c = cmd
 		}
+		err = usageError(err)
 		if !c.SilenceErrors {
 			c.PrintErrln(c.ErrPrefix(), err.Error())
 			c.PrintErrf("Run '%v --help' for usage.\n", c.CommandPath())
@@ -1170,6 +1380,14 @@ func (c *Command) ExecuteC() (cmd *Command, err error) {
 }
 
 func (c *Command) ValidateArgs(args []string) error {
+	if c.HasArgDefs() {
+		if err := c.validateArgDefs(args); err != nil {
+			return err
+		}
+		if c.Args == nil {
+			return nil
+		}
+	}
 	if c.Args == nil {
 		return ArbitraryArgs(c, args)
 	}
@@ -1195,7 +1413,7 @@ func (c *Command) ValidateRequiredFlags() error {
 	})
 
 	if len(missingFlagNames) > 0 {
-		return fmt.Errorf(`required flag(s) "%s" not set`, strings.Join(missingFlagNames, `", "`))
+		return &RequiredFlagsError{Flags: missingFlagNames}
 	}
 	return nil
 }
@@ -1303,10 +1521,12 @@ Simply type ` + c.DisplayName() + ` help [path to command] for full details.`,
 
 					cmd.InitDefaultHelpFlag()    // make possible 'help' flag to be shown
 					cmd.InitDefaultVersionFlag() // make possible 'version' flag to be shown
+					cmd.InitDefaultConfirmFlag() // make possible 'yes' flag to be shown
 					CheckErr(cmd.Help())
 				}
 			},
-			GroupID: c.helpCommandGroupID,
+			GroupID:   c.helpCommandGroupID,
+			autoAdded: true,
 		}
 	}
 	c.RemoveCommand(c.helpCommand)
@@ -1331,7 +1551,7 @@ func (c commandSorterByName) Less(i, j int) bool { return c[i].Name() < c[j].Nam
 // Commands returns a sorted slice of child commands.
 func (c *Command) Commands() []*Command {
 	// do not sort commands if it already sorted or sorting was disabled
-	if EnableCommandSorting && !c.commandsAreSorted {
+	if c.opts().commandSorting() && !c.commandsAreSorted {
 		sort.Sort(commandSorterByName(c.commands))
 		c.commandsAreSorted = true
 	}
@@ -1487,6 +1707,10 @@ func (c *Command) UseLine() string {
 	} else {
 		useline = use
 	}
+	if c.HasArgDefs() && !strings.Contains(c.Use, " ") {
+		// Only the name of the command is specified, let's add the arguments
+		useline += " " + c.argDefsSynopsis()
+	}
 	if c.DisableFlagsInUseLine {
 		return useline
 	}
@@ -1497,7 +1721,7 @@ func (c *Command) UseLine() string {
 }
 
 // DebugFlags used to determine which flags have been assigned to which commands
-// and which persist.
+// and which persist, as well as where the value of each flag came from.
 func (c *Command) DebugFlags() {
 	c.Println("DebugFlags called on", c.Name())
 	var debugflags func(*Command)
@@ -1509,9 +1733,9 @@ func (c *Command) DebugFlags() {
 		if x.HasFlags() {
 			x.flags.VisitAll(func(f *flag.Flag) {
 				if x.HasPersistentFlags() && x.persistentFlag(f.Name) != nil {
-					c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+f.DefValue+"]", "", f.Value, "  [LP]")
+					c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+f.DefValue+"]", "", f.Value, "  [LP]", "("+flagSource(f)+")")
 				} else {
-					c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+f.DefValue+"]", "", f.Value, "  [L]")
+					c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+f.DefValue+"]", "", f.Value, "  [L]", "("+flagSource(f)+")")
 				}
 			})
 		}
@@ -1519,10 +1743,10 @@ func (c *Command) DebugFlags() {
 			x.pflags.VisitAll(func(f *flag.Flag) {
 				if x.HasFlags() {
 					if x.flags.Lookup(f.Name) == nil {
-						c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+f.DefValue+"]", "", f.Value, "  [P]")
+						c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+f.DefValue+"]", "", f.Value, "  [P]", "("+flagSource(f)+")")
 					}
 				} else {
-					c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+f.DefValue+"]", "", f.Value, "  [P]")
+					c.Println("  -"+f.Shorthand+",", "--"+f.Name, "["+f.DefValue+"]", "", f.Value, "  [P]", "("+flagSource(f)+")")
 				}
 			})
 		}
@@ -1550,7 +1774,7 @@ func (c *Command) Name() string {
 // HasAlias determines if a given string is an alias of the command.
 func (c *Command) HasAlias(s string) bool {
 	for _, a := range c.Aliases {
-		if commandNameMatches(a, s) {
+		if c.commandNameMatches(a, s) {
 			return true
 		}
 	}
@@ -1613,7 +1837,8 @@ func (c *Command) IsAvailableCommand() bool {
 		return false
 	}
 
-	if c.Runnable() || c.HasAvailableSubCommands() {
+	// The stub of a lazy command is shown until the command is built
+	if c.Runnable() || c.HasAvailableSubCommands() || c.lazyBuild != nil {
 		return true
 	}
 
@@ -1626,8 +1851,8 @@ func (c *Command) IsAvailableCommand() bool {
 // are runnable/hidden/deprecated.
 // Concrete example: https://github.com/spf13/cobra/issues/393#issuecomment-282741924.
 func (c *Command) IsAdditionalHelpTopicCommand() bool {
-	// if a command is runnable, deprecated, or hidden it is not a 'help' command
-	if c.Runnable() || len(c.Deprecated) != 0 || c.Hidden {
+	// if a command is runnable, lazy, deprecated, or hidden it is not a 'help' command
+	if c.Runnable() || c.lazyBuild != nil || len(c.Deprecated) != 0 || c.Hidden {
 		return false
 	}
 
@@ -1885,6 +2110,15 @@ func (c *Command) ParseFlags(args []string) error {
 		c.Print(c.flagErrorBuf.String())
 	}
 
+	var notExistErr *flag.NotExistError
+	if errors.As(err, &notExistErr) {
+		return &UnknownFlagError{
+			Name:        notExistErr.GetSpecifiedName(),
+			Shorthands:  notExistErr.GetSpecifiedShortnames(),
+			CommandPath: c.CommandPath(),
+			Err:         err,
+		}
+	}
 	return err
 }
 
@@ -1924,9 +2158,9 @@ func (c *Command) updateParentsPflags() {
 
 // commandNameMatches checks if two command names are equal
 // taking into account case sensitivity according to
-// EnableCaseInsensitive global configuration.
-func commandNameMatches(s string, t string) bool {
-	if EnableCaseInsensitive {
+// the EnableCaseInsensitive configuration of the command.
+func (c *Command) commandNameMatches(s string, t string) bool {
+	if c.opts().caseInsensitive() {
 		return strings.EqualFold(s, t)
 	}
 
@@ -1937,6 +2171,13 @@ func commandNameMatches(s string, t string) bool {
 type tmplFunc struct {
 	tmpl string
 	fn   func(io.Writer, interface{}) error
+
+	// mu protects the template parsed with the template functions of opts,
+	// when their version was funcsVersion.
+	mu           sync.Mutex
+	parsed       *template.Template
+	opts         *Options
+	funcsVersion uint64
 }
 
 const defaultUsageTemplate = `Usage:{{if .Runnable}}
@@ -1956,13 +2197,16 @@ Available Commands:{{range $cmds}}{{if (or .IsAvailableCommand (eq .Name "help")
   {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if not .AllChildCommandsHaveGroup}}
 
 Additional Commands:{{range $cmds}}{{if (and (eq .GroupID "") (or .IsAvailableCommand (eq .Name "help")))}}
-  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{end}}{{end}}{{if .HasArgDefs}}
+
+Arguments:
+{{.ArgDefUsages | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableLocalFlags}}
 
 Flags:
-{{.LocalFlags.FlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableInheritedFlags}}
+{{(.FlagsWithEnvUsage .LocalFlags).FlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableInheritedFlags}}
 
 Global Flags:
-{{.InheritedFlags.FlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasHelpSubCommands}}
+{{(.FlagsWithEnvUsage .InheritedFlags).FlagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasHelpSubCommands}}
 
 Additional help topics:{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
   {{rpad .CommandPath .CommandPathPadding}} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableSubCommands}}
@@ -2016,13 +2260,17 @@ func defaultUsageFunc(w io.Writer, in interface{}) error {
 			}
 		}
 	}
+	if c.HasArgDefs() {
+		fmt.Fprintf(w, "\n\nArguments:\n")
+		fmt.Fprint(w, trimRightSpace(c.ArgDefUsages()))
+	}
 	if c.HasAvailableLocalFlags() {
 		fmt.Fprintf(w, "\n\nFlags:\n")
-		fmt.Fprint(w, trimRightSpace(c.LocalFlags().FlagUsages()))
+		fmt.Fprint(w, trimRightSpace(c.FlagsWithEnvUsage(c.LocalFlags()).FlagUsages()))
 	}
 	if c.HasAvailableInheritedFlags() {
 		fmt.Fprintf(w, "\n\nGlobal Flags:\n")
-		fmt.Fprint(w, trimRightSpace(c.InheritedFlags().FlagUsages()))
+		fmt.Fprint(w, trimRightSpace(c.FlagsWithEnvUsage(c.InheritedFlags()).FlagUsages()))
 	}
 	if c.HasHelpSubCommands() {
 		fmt.Fprintf(w, "\n\nAdditional help topics:")
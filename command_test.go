@@ -17,6 +17,7 @@ package cobra
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -1165,6 +1166,124 @@ func TestSetUsageTemplate(t *testing.T) {
 	}
 }
 
+func TestValidateTemplates(t *testing.T) {
+	rootCmd := &Command{Use: "root", Short: "short", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.SetHelpTemplate("HELP {{.Short}}")
+	if err := rootCmd.ValidateTemplates(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	testcases := []struct {
+		set      func(*Command, string)
+		text     string
+		expected string
+	}{
+		{(*Command).SetUsageTemplate, "{{.Short", `invalid usage template of "root child": `},
+		{(*Command).SetHelpTemplate, "{{undefined .Short}}", `invalid help template of "root child": `},
+		{(*Command).SetVersionTemplate, "{{.Short", `invalid version template of "root child": `},
+	}
+	for _, tc := range testcases {
+		// Invalid templates are accepted, and reported when validated or executed
+		tc.set(childCmd, tc.text)
+		if err := rootCmd.ValidateTemplates(); err == nil || !strings.HasPrefix(err.Error(), tc.expected) {
+			t.Errorf("%q: expected an error starting with %q, got %v", tc.text, tc.expected, err)
+		}
+		tc.set(childCmd, "")
+	}
+
+	// The help prints the error instead of panicking
+	childCmd.SetHelpTemplate("{{.Short")
+	output, err := executeCommand(rootCmd, "child", "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "unclosed action")
+	output, err = executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if output != "HELP short" {
+		t.Errorf("Expected the help template of the root command, got %q", output)
+	}
+}
+
+func TestSetTemplateE(t *testing.T) {
+	opts := NewOptions()
+	opts.AddTemplateFunc("shout", strings.ToUpper)
+	rootCmd := &Command{Use: "root", Version: "1.0", Run: emptyRun}
+	rootCmd.SetOptions(opts)
+
+	testcases := []struct {
+		set    func(*Command, string) error
+		output func(*Command) string
+	}{
+		{(*Command).SetUsageTemplateE, (*Command).UsageString},
+		{(*Command).SetHelpTemplateE, func(c *Command) string {
+			c.ResetState()
+			output, _ := executeCommand(c, "--help")
+			return output
+		}},
+		{(*Command).SetVersionTemplateE, func(c *Command) string {
+			c.ResetState()
+			output, _ := executeCommand(c, "--version")
+			return output
+		}},
+	}
+	for i, tc := range testcases {
+		// The template functions of the options are available when the template is set
+		assertNoErr(t, tc.set(rootCmd, "{{shout .Name}}"))
+		if output := tc.output(rootCmd); output != "ROOT" {
+			t.Errorf("%d: expected the template to be set, got %q", i, output)
+		}
+
+		// An invalid template is rejected and leaves the previous one
+		if err := tc.set(rootCmd, "{{undefined .Name}}"); err == nil || !strings.Contains(err.Error(), `function "undefined" not defined`) {
+			t.Errorf("%d: expected an error for the undefined function, got %v", i, err)
+		}
+		if output := tc.output(rootCmd); output != "ROOT" {
+			t.Errorf("%d: expected the previous template to be kept, got %q", i, output)
+		}
+
+		assertNoErr(t, tc.set(rootCmd, ""))
+		if output := tc.output(rootCmd); output == "ROOT" {
+			t.Errorf("%d: expected the default template, got %q", i, output)
+		}
+	}
+}
+
+func TestTemplateParsedOnce(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.SetUsageTemplate("USAGE {{.UseLine}}")
+	if rootCmd.usageTemplate.parsed != nil {
+		t.Error("Expected the template not to be parsed when set")
+	}
+	assertNoErr(t, childCmd.SetUsageTemplateE("USAGE {{.UseLine}}"))
+	if childCmd.usageTemplate.parsed == nil {
+		t.Error("Expected the template to be parsed when set with SetUsageTemplateE")
+	}
+
+	checkStringContains(t, rootCmd.UsageString(), "USAGE root")
+	parsed := rootCmd.usageTemplate.parsed
+	for _, cmd := range []*Command{rootCmd, childCmd, rootCmd} {
+		checkStringContains(t, cmd.UsageString(), "USAGE "+cmd.UseLine())
+	}
+	if rootCmd.usageTemplate.parsed != parsed {
+		t.Error("Expected the template to be parsed once")
+	}
+
+	// Adding template functions parses the template again
+	AddTemplateFunc("unusedFunc", strings.ToUpper)
+	defer delete(templateFuncs, "unusedFunc")
+	checkStringContains(t, rootCmd.UsageString(), "USAGE root")
+	if rootCmd.usageTemplate.parsed == parsed {
+		t.Error("Expected the template to be parsed again with the new template functions")
+	}
+}
+
 func TestVersionFlagExecuted(t *testing.T) {
 	rootCmd := &Command{Use: "root", Version: "1.0.0", Run: emptyRun}
 
@@ -1785,6 +1904,99 @@ func testPersistentHooks(t *testing.T, expectedHookRunOrder []string) {
 	}
 }
 
+func TestFinallyHooks(t *testing.T) {
+	preRunErr := errors.New("pre-run failed")
+	runErr := errors.New("run failed")
+
+	testcases := []struct {
+		desc        string
+		preRunErr   error
+		runErr      error
+		expectedErr string
+	}{
+		{desc: "success", expectedErr: "grandparent: parent: child: <nil>"},
+		{desc: "PreRunE fails", preRunErr: preRunErr, expectedErr: "grandparent: parent: child: pre-run failed"},
+		{desc: "RunE fails", runErr: runErr, expectedErr: "grandparent: parent: child: run failed"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var hookRunOrder []string
+			finally := func(name string) func(*Command, []string, error) error {
+				return func(cmd *Command, args []string, err error) error {
+					hookRunOrder = append(hookRunOrder, name)
+					if cmd.Name() != "child" || strings.Join(args, " ") != onetwo {
+						t.Errorf("Unexpected command %q and args %v in %s", cmd.Name(), args, name)
+					}
+					return fmt.Errorf("%s: %v", strings.Fields(name)[0], err)
+				}
+			}
+
+			grandparentCmd := &Command{Use: "grandparent", PersistentFinally: finally("grandparent PersistentFinally")}
+			parentCmd := &Command{
+				Use:               "parent",
+				Finally:           finally("parent Finally"),
+				PersistentFinally: finally("parent PersistentFinally"),
+			}
+			childCmd := &Command{
+				Use:     "child",
+				PreRunE: func(*Command, []string) error { return tc.preRunErr },
+				RunE:    func(*Command, []string) error { return tc.runErr },
+				Finally: finally("child Finally"),
+			}
+			grandparentCmd.AddCommand(parentCmd)
+			parentCmd.AddCommand(childCmd)
+
+			_, err := executeCommand(grandparentCmd, "parent", "child", "one", "two")
+			if err == nil || err.Error() != tc.expectedErr {
+				t.Errorf("Expected error %q, got %v", tc.expectedErr, err)
+			}
+			expected := []string{"child Finally", "parent PersistentFinally", "grandparent PersistentFinally"}
+			if !reflect.DeepEqual(hookRunOrder, expected) {
+				t.Errorf("Expected hooks %v, got %v", expected, hookRunOrder)
+			}
+		})
+	}
+}
+
+func TestFinallyHookClearsError(t *testing.T) {
+	rootCmd := &Command{
+		Use:     "root",
+		RunE:    func(*Command, []string) error { return errors.New("failed") },
+		Finally: func(*Command, []string, error) error { return nil },
+	}
+
+	if _, err := executeCommand(rootCmd); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestFinallyHookOnPanic(t *testing.T) {
+	var called bool
+	rootCmd := &Command{
+		Use: "root",
+		Run: func(*Command, []string) { panic("boom") },
+		PersistentFinally: func(_ *Command, _ []string, err error) error {
+			called = true
+			if err != nil {
+				t.Errorf("Expected no error on panic, got %v", err)
+			}
+			return errors.New("ignored")
+		},
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Expected the panic to be propagated, got %v", r)
+			}
+		}()
+		_, _ = executeCommand(rootCmd)
+	}()
+	if !called {
+		t.Error("Expected the PersistentFinally hook to run on panic")
+	}
+}
+
 // Related to https://github.com/spf13/cobra/issues/521.
 func TestGlobalNormFuncPropagation(t *testing.T) {
 	normFunc := func(f *pflag.FlagSet, name string) pflag.NormalizedName {
@@ -28,10 +28,11 @@ import (
 var preExecHookFn = preExecHook
 
 func preExecHook(c *Command) {
-	if MousetrapHelpText != "" && mousetrap.StartedByExplorer() {
-		c.Print(MousetrapHelpText)
-		if MousetrapDisplayDuration > 0 {
-			time.Sleep(MousetrapDisplayDuration)
+	helpText, displayDuration := c.opts().mousetrap()
+	if helpText != "" && mousetrap.StartedByExplorer() {
+		c.Print(helpText)
+		if displayDuration > 0 {
+			time.Sleep(displayDuration)
 		} else {
 			c.Println("Press return to continue...")
 			fmt.Scanln()
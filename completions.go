@@ -15,12 +15,12 @@
 package cobra
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/spf13/pflag"
 )
@@ -32,14 +32,12 @@ const (
 	// ShellCompNoDescRequestCmd is the name of the hidden command that is used to request
 	// completion results without their description.  It is used by the shell completion scripts.
 	ShellCompNoDescRequestCmd = "__completeNoDesc"
+	// ShellCompJSONRequestCmd is the name of the hidden command that is used to request
+	// completion results as a JSON document.  It is meant for tools other than shells,
+	// such as editors, which need structured completion results.
+	ShellCompJSONRequestCmd = "__completeJSON"
 )
 
-// Global map of flag completion functions. Make sure to use flagCompletionMutex before you try to read and write from it.
-var flagCompletionFunctions = map[*pflag.Flag]CompletionFunc{}
-
-// lock for reading and writing from flagCompletionFunctions
-var flagCompletionMutex = &sync.RWMutex{}
-
 // ShellCompDirective is a bit map representing the different behaviors the shell
 // can be instructed to have once completions have been provided.
 type ShellCompDirective int
@@ -163,6 +161,31 @@ func FixedCompletions(choices []Completion, directive ShellCompDirective) Comple
 	}
 }
 
+// FileCompletions can be used to create a completion function which completes
+// file names.  If extensions are specified, only the files with one of these
+// extensions are completed.
+//
+// This method returns a function that satisfies [CompletionFunc]
+// It can be used with [Command.RegisterArgCompletionFunc] and [Command.RegisterFlagCompletionFunc].
+func FileCompletions(extensions ...string) CompletionFunc {
+	if len(extensions) == 0 {
+		return FixedCompletions(nil, ShellCompDirectiveDefault)
+	}
+	return FixedCompletions(extensions, ShellCompDirectiveFilterFileExt)
+}
+
+// DirCompletions can be used to create a completion function which completes
+// directory names within dir, or within the current directory if dir is empty.
+//
+// This method returns a function that satisfies [CompletionFunc]
+// It can be used with [Command.RegisterArgCompletionFunc] and [Command.RegisterFlagCompletionFunc].
+func DirCompletions(dir string) CompletionFunc {
+	if dir == "" {
+		return FixedCompletions(nil, ShellCompDirectiveFilterDirs)
+	}
+	return FixedCompletions([]Completion{dir}, ShellCompDirectiveFilterDirs)
+}
+
 // RegisterFlagCompletionFunc should be called to register a function to provide completion for a flag.
 //
 // You can use pre-defined completion functions such as [FixedCompletions] or [NoFileCompletions],
@@ -172,14 +195,11 @@ func (c *Command) RegisterFlagCompletionFunc(flagName string, f CompletionFunc)
 	if flag == nil {
 		return fmt.Errorf("RegisterFlagCompletionFunc: flag '%s' does not exist", flagName)
 	}
-	flagCompletionMutex.Lock()
-	defer flagCompletionMutex.Unlock()
-
-	if _, exists := flagCompletionFunctions[flag]; exists {
+	o := c.opts()
+	if _, exists := o.flagCompletionFunc(flag); exists {
 		return fmt.Errorf("RegisterFlagCompletionFunc: flag '%s' already registered", flagName)
 	}
-	flagCompletionFunctions[flag] = f
-	return nil
+	return o.registerFlagCompletionFunc(flag, f)
 }
 
 // GetFlagCompletionFunc returns the completion function for the given flag of the command, if available.
@@ -189,11 +209,49 @@ func (c *Command) GetFlagCompletionFunc(flagName string) (CompletionFunc, bool)
 		return nil, false
 	}
 
-	flagCompletionMutex.RLock()
-	defer flagCompletionMutex.RUnlock()
+	return c.opts().flagCompletionFunc(flag)
+}
 
-	completionFunc, exists := flagCompletionFunctions[flag]
-	return completionFunc, exists
+// RegisterArgCompletionFunc should be called to register a function to provide completion
+// for the positional argument at the given index, starting at 0.  It takes precedence over
+// ValidArgsFunction for that argument.
+//
+// You can use pre-defined completion functions such as [FixedCompletions], [FileCompletions],
+// [DirCompletions] or [NoFileCompletions], or you can define your own.
+func (c *Command) RegisterArgCompletionFunc(index int, f CompletionFunc) error {
+	if index < 0 {
+		return fmt.Errorf("RegisterArgCompletionFunc: invalid argument index %d", index)
+	}
+	if _, exists := c.argCompletionFuncs[index]; exists {
+		return fmt.Errorf("RegisterArgCompletionFunc: argument %d already registered", index)
+	}
+	if c.argCompletionFuncs == nil {
+		c.argCompletionFuncs = make(map[int]CompletionFunc)
+	}
+	c.argCompletionFuncs[index] = f
+	return nil
+}
+
+// RegisterRestArgsCompletionFunc should be called to register a function to provide completion
+// for the positional arguments which have no completion function registered for their index.
+func (c *Command) RegisterRestArgsCompletionFunc(f CompletionFunc) error {
+	if c.restArgsCompletionFunc != nil {
+		return fmt.Errorf("RegisterRestArgsCompletionFunc: already registered")
+	}
+	c.restArgsCompletionFunc = f
+	return nil
+}
+
+// GetArgCompletionFunc returns the completion function for the positional argument
+// at the given index of the command, if available.
+func (c *Command) GetArgCompletionFunc(index int) (CompletionFunc, bool) {
+	if completionFunc, exists := c.argCompletionFuncs[index]; exists {
+		return completionFunc, true
+	}
+	if c.restArgsCompletionFunc != nil && index >= 0 {
+		return c.restArgsCompletionFunc, true
+	}
+	return nil, false
 }
 
 // Returns a string listing the different directive enabled in the specified parameter
@@ -231,11 +289,12 @@ func (d ShellCompDirective) string() string {
 func (c *Command) initCompleteCmd(args []string) {
 	completeCmd := &Command{
 		Use:                   fmt.Sprintf("%s [command-line]", ShellCompRequestCmd),
-		Aliases:               []string{ShellCompNoDescRequestCmd},
+		Aliases:               []string{ShellCompNoDescRequestCmd, ShellCompJSONRequestCmd},
 		DisableFlagsInUseLine: true,
 		Hidden:                true,
 		DisableFlagParsing:    true,
 		Args:                  MinimumNArgs(1),
+		autoAdded:             true,
 		Short:                 "Request shell completion choices for the specified command-line",
 		Long: fmt.Sprintf("%[2]s is a special command that is used by the shell completion logic\n%[1]s",
 			"to request completion choices for the specified command-line.", ShellCompRequestCmd),
@@ -256,6 +315,15 @@ func (c *Command) initCompleteCmd(args []string) {
 			}
 			noActiveHelp := GetActiveHelpConfig(finalCmd) == activeHelpGlobalDisable
 			out := finalCmd.OutOrStdout()
+
+			if cmd.CalledAs() == ShellCompJSONRequestCmd {
+				result := newCompletionJSON(finalCmd, completions, directive, err, noDescriptions, noActiveHelp)
+				if err := json.NewEncoder(out).Encode(result); err != nil {
+					CompErrorln(err.Error())
+				}
+				return
+			}
+
 			for _, comp := range completions {
 				if noActiveHelp && strings.HasPrefix(comp, activeHelpMarker) {
 					// Remove all activeHelp entries if it's disabled.
@@ -322,6 +390,11 @@ func (c *Command) getCompletions(args []string) (*Command, []Completion, ShellCo
 	trimmedArgs := make([]string, len(args)-1)
 	copy(trimmedArgs, args[:len(args)-1])
 
+	// Continue the completion from the expansion of a user alias
+	if expanded, err := c.Root().expandUserAliases(trimmedArgs); err == nil {
+		trimmedArgs = expanded
+	}
+
 	var finalCmd *Command
 	var finalArgs []string
 	var err error
@@ -356,6 +429,7 @@ func (c *Command) getCompletions(args []string) (*Command, []Completion, ShellCo
 	if !finalCmd.DisableFlagParsing {
 		finalCmd.InitDefaultHelpFlag()
 		finalCmd.InitDefaultVersionFlag()
+		finalCmd.InitDefaultConfirmFlag()
 	}
 
 	// Check if we are doing flag value completion before parsing the flags.
@@ -430,7 +504,8 @@ func (c *Command) getCompletions(args []string) (*Command, []Completion, ShellCo
 	var directive ShellCompDirective
 
 	// Enforce flag groups before doing flag completions
-	finalCmd.enforceFlagGroupsForCompletion()
+	restoreFlags := finalCmd.enforceFlagGroupsForCompletion()
+	defer restoreFlags()
 
 	// Note that we want to perform flagname completion even if finalCmd.DisableFlagParsing==true;
 	// doing this allows for completion of persistent flag names even for commands that disable flag parsing.
@@ -565,25 +640,47 @@ func (c *Command) getCompletions(args []string) (*Command, []Completion, ShellCo
 	}
 
 	// Find the completion function for the flag or command
-	var completionFn CompletionFunc
-	if flag != nil && flagCompletion {
-		flagCompletionMutex.RLock()
-		completionFn = flagCompletionFunctions[flag]
-		flagCompletionMutex.RUnlock()
-	} else {
-		completionFn = finalCmd.ValidArgsFunction
+	if !flagCompletion {
+		flag = nil
 	}
-	if completionFn != nil {
+	if completionFn := finalCmd.completionFunc(flag, len(finalArgs)); completionFn != nil {
 		// Go custom completion defined for this flag or command.
 		// Call the registered completion function to get the completions.
 		var comps []Completion
-		comps, directive = completionFn(finalCmd, finalArgs, toComplete)
+		comps, directive = finalCmd.callCompletionFunc(completionFn, flag, finalArgs, toComplete)
 		completions = append(completions, comps...)
 	}
 
 	return finalCmd, completions, directive, nil
 }
 
+// completionFunc returns the function completing the value of flag if it is not nil,
+// or else the positional argument at position pos, or nil.
+func (c *Command) completionFunc(flag *pflag.Flag, pos int) CompletionFunc {
+	if flag != nil {
+		completionFn, _ := c.opts().flagCompletionFunc(flag)
+		return completionFn
+	}
+	if argFn, exists := c.GetArgCompletionFunc(pos); exists {
+		return argFn
+	}
+	if c.ValidArgsFunction == nil && c.HasArgDefs() {
+		// Complete the arguments based on their declaration
+		return completeArgDefs
+	}
+	return c.ValidArgsFunction
+}
+
+// callCompletionFunc calls completionFn, which completes the value of flag if it is not nil.
+func (c *Command) callCompletionFunc(completionFn CompletionFunc, flag *pflag.Flag, args []string, toComplete string) ([]Completion, ShellCompDirective) {
+	if flag != nil {
+		// Tell CachedCompletions which flag is completed
+		c.completedFlag = flag
+		defer func() { c.completedFlag = nil }()
+	}
+	return completionFn(c, args, toComplete)
+}
+
 func helpOrVersionFlagPresent(cmd *Command) bool {
 	if versionFlag := cmd.Flags().Lookup("version"); versionFlag != nil &&
 		len(versionFlag.Annotations[FlagSetByCobraAnnotation]) > 0 && versionFlag.Changed {
@@ -762,8 +859,8 @@ func (c *Command) InitDefaultCompletionCmd(args ...string) {
 	// Special case to know if there are sub-commands or not.
 	hasSubCommands := false
 	for _, cmd := range c.commands {
-		if cmd.Name() != ShellCompRequestCmd && cmd.Name() != helpCommandName {
-			// We found a real sub-command (not 'help' or '__complete')
+		if cmd.Name() != ShellCompRequestCmd && cmd.Name() != ShellCompServerRequestCmd && cmd.Name() != helpCommandName {
+			// We found a real sub-command (not 'help', '__complete' or '__completeServer')
 			hasSubCommands = true
 			break
 		}
@@ -779,6 +876,7 @@ See each sub-command's help for details on how to use the generated script.
 		ValidArgsFunction: NoFileCompletions,
 		Hidden:            c.CompletionOptions.HiddenDefaultCmd,
 		GroupID:           c.completionCommandGroupID,
+		autoAdded:         true,
 	}
 	c.AddCommand(completionCmd)
 
@@ -926,7 +1024,74 @@ to your powershell profile.
 		powershell.Flags().BoolVar(&noDesc, compCmdNoDescFlagName, compCmdNoDescFlagDefault, compCmdNoDescFlagDesc)
 	}
 
-	completionCmd.AddCommand(bash, zsh, fish, powershell)
+	nushell := &Command{
+		Use:   "nushell",
+		Short: fmt.Sprintf(shortDesc, "nushell"),
+		Long: fmt.Sprintf(`Generate the autocompletion script for the nushell shell.
+
+To load completions for every new session, execute once:
+
+	%[1]s completion nushell | save --force ($nu.default-config-dir | path join "%[1]s_completion.nu")
+
+and add the following line to your config.nu file:
+
+	source ($nu.default-config-dir | path join "%[1]s_completion.nu")
+
+You will need to start a new shell for this setup to take effect.
+`, c.Root().Name()),
+		Args:              NoArgs,
+		ValidArgsFunction: NoFileCompletions,
+		RunE: func(cmd *Command, args []string) error {
+			return cmd.Root().GenNushellCompletion(out, !noDesc)
+		},
+	}
+	if haveNoDescFlag {
+		nushell.Flags().BoolVar(&noDesc, compCmdNoDescFlagName, compCmdNoDescFlagDefault, compCmdNoDescFlagDesc)
+	}
+
+	elvish := &Command{
+		Use:   "elvish",
+		Short: fmt.Sprintf(shortDesc, "elvish"),
+		Long: fmt.Sprintf(`Generate the autocompletion script for the elvish shell.
+
+To load completions in your current shell session:
+
+	eval (%[1]s completion elvish | slurp)
+
+To load completions for every new session, add the above line to your rc.elv file.
+`, c.Root().Name()),
+		Args:              NoArgs,
+		ValidArgsFunction: NoFileCompletions,
+		RunE: func(cmd *Command, args []string) error {
+			return cmd.Root().GenElvishCompletion(out, !noDesc)
+		},
+	}
+	if haveNoDescFlag {
+		elvish.Flags().BoolVar(&noDesc, compCmdNoDescFlagName, compCmdNoDescFlagDefault, compCmdNoDescFlagDesc)
+	}
+
+	xonsh := &Command{
+		Use:   "xonsh",
+		Short: fmt.Sprintf(shortDesc, "xonsh"),
+		Long: fmt.Sprintf(`Generate the autocompletion script for the xonsh shell.
+
+To load completions in your current shell session:
+
+	execx($(%[1]s completion xonsh))
+
+To load completions for every new session, add the above line to your ~/.xonshrc file.
+`, c.Root().Name()),
+		Args:              NoArgs,
+		ValidArgsFunction: NoFileCompletions,
+		RunE: func(cmd *Command, args []string) error {
+			return cmd.Root().GenXonshCompletion(out, !noDesc)
+		},
+	}
+	if haveNoDescFlag {
+		xonsh.Flags().BoolVar(&noDesc, compCmdNoDescFlagName, compCmdNoDescFlagDefault, compCmdNoDescFlagDesc)
+	}
+
+	completionCmd.AddCommand(bash, zsh, fish, powershell, nushell, elvish, xonsh)
 }
 
 func findFlag(cmd *Command, name string) *pflag.Flag {
@@ -996,6 +1161,7 @@ func CompErrorln(msg string) {
 const (
 	configEnvVarGlobalPrefix       = "COBRA"
 	configEnvVarSuffixDescriptions = "COMPLETION_DESCRIPTIONS"
+	configEnvVarSuffixCache        = "COMPLETION_CACHE"
 )
 
 var configEnvVarPrefixSubstRegexp = regexp.MustCompile(`[^A-Z0-9_]`)
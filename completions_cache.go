@@ -0,0 +1,178 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+)
+
+// compCacheDirName is the directory, within the user cache directory, where
+// the completion results of all programs are cached.
+const compCacheDirName = "cobra-completions"
+
+// userCacheDir returns the user cache directory.  It is a variable for testing purposes.
+var userCacheDir = os.UserCacheDir
+
+// compCacheEntry is a cached result of a completion function.
+type compCacheEntry struct {
+	Expires     time.Time          `json:"expires"`
+	Completions []Completion       `json:"completions"`
+	Directive   ShellCompDirective `json:"directive"`
+}
+
+// CachedCompletions can be used to wrap a completion function whose results are
+// expensive to compute, for example because they are obtained from a remote service.
+// The results of f are stored on disk under the user cache directory and are
+// reused for the duration of ttl.  Results are cached separately for each command,
+// completed flag, arguments, flag values and prefix to complete.  Results with the
+// ShellCompDirectiveError directive are never cached.
+//
+// Caching can be disabled by users by setting the <PROGRAM>_COMPLETION_CACHE
+// environment variable (falling back to COBRA_COMPLETION_CACHE if empty or not set)
+// to a falsey value.  Use [ClearCompletionCache] to invalidate the cached results.
+//
+// This method returns a function that satisfies [CompletionFunc].
+func CachedCompletions(f CompletionFunc, ttl time.Duration) CompletionFunc {
+	return func(cmd *Command, args []string, toComplete string) ([]Completion, ShellCompDirective) {
+		if !completionCacheEnabled(cmd) {
+			return f(cmd, args, toComplete)
+		}
+
+		dir, err := completionCacheDir(cmd)
+		if err != nil {
+			CompDebugln("Completion cache disabled: "+err.Error(), false)
+			return f(cmd, args, toComplete)
+		}
+		path := filepath.Join(dir, completionCacheKey(cmd, args, toComplete)+".json")
+
+		if entry, ok := readCompCacheEntry(path); ok {
+			CompDebugln("Using cached completions from "+path, false)
+			return entry.Completions, entry.Directive
+		}
+
+		completions, directive := f(cmd, args, toComplete)
+		if directive&ShellCompDirectiveError == 0 {
+			entry := compCacheEntry{
+				Expires:     time.Now().Add(ttl),
+				Completions: completions,
+				Directive:   directive,
+			}
+			if err := writeCompCacheEntry(dir, path, entry); err != nil {
+				CompDebugln("Unable to cache completions: "+err.Error(), false)
+			}
+		}
+		return completions, directive
+	}
+}
+
+// ClearCompletionCache removes all the completion results cached by
+// [CachedCompletions] for the program of cmd.
+func ClearCompletionCache(cmd *Command) error {
+	dir, err := completionCacheDir(cmd)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// completionCacheEnabled returns false if the user disabled the completion cache
+// through the <PROGRAM>_COMPLETION_CACHE or COBRA_COMPLETION_CACHE environment variables.
+func completionCacheEnabled(cmd *Command) bool {
+	enabled, err := strconv.ParseBool(getEnvConfig(cmd, configEnvVarSuffixCache))
+	return err != nil || enabled
+}
+
+// completionCacheDir returns the directory where the completion results
+// of the program of cmd are cached.
+func completionCacheDir(cmd *Command) (string, error) {
+	base, err := userCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, compCacheDirName, cmd.Root().Name()), nil
+}
+
+// completionCacheKey returns the name of the cache entry for the specified completion request.
+func completionCacheKey(cmd *Command, args []string, toComplete string) string {
+	// Flags which have been set may change the completions, so they are part of the key
+	var setFlags []string
+	cmd.Flags().Visit(func(f *flag.Flag) {
+		setFlags = append(setFlags, f.Name+"="+f.Value.String())
+	})
+	sort.Strings(setFlags)
+
+	// The completion functions of the flags and of the arguments are cached separately
+	completed := ""
+	if cmd.completedFlag != nil {
+		completed = "--" + cmd.completedFlag.Name
+	}
+
+	h := sha256.New()
+	for _, part := range [][]string{{cmd.CommandPath(), completed}, args, setFlags, {toComplete}} {
+		// Use a separator which cannot be typed on a command-line between the parts
+		h.Write([]byte(strings.Join(part, "\x00") + "\x01"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func readCompCacheEntry(path string) (compCacheEntry, bool) {
+	var entry compCacheEntry
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil || time.Now().After(entry.Expires) {
+		// The entry is corrupted or stale
+		os.Remove(path)
+		return entry, false
+	}
+	return entry, true
+}
+
+func writeCompCacheEntry(dir, path string, entry compCacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	// Write to a temporary file first so a concurrent completion
+	// request never reads a partially written entry.
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
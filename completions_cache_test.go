@@ -0,0 +1,177 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupCompletionCache(t *testing.T) {
+	dir := t.TempDir()
+	userCacheDir = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { userCacheDir = os.UserCacheDir })
+}
+
+func countingCompletionCmd(ttl time.Duration) (*Command, *int) {
+	calls := 0
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{
+		Use: "child",
+		ValidArgsFunction: CachedCompletions(func(cmd *Command, args []string, toComplete string) ([]Completion, ShellCompDirective) {
+			calls++
+			return []Completion{fmt.Sprintf("call%d", calls)}, ShellCompDirectiveNoFileComp
+		}, ttl),
+		Run: emptyRun,
+	}
+	childCmd.Flags().String("ns", "", "namespace")
+	rootCmd.AddCommand(childCmd)
+	return rootCmd, &calls
+}
+
+func TestCachedCompletions(t *testing.T) {
+	setupCompletionCache(t)
+	rootCmd, calls := countingCompletionCmd(time.Hour)
+
+	for i := 0; i < 2; i++ {
+		output, err := executeCommand(rootCmd, ShellCompRequestCmd, "child", "")
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		expected := strings.Join([]string{"call1", ":4", "Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+		if output != expected {
+			t.Errorf("expected: %q, got: %q", expected, output)
+		}
+	}
+	if *calls != 1 {
+		t.Errorf("Expected the completion function to be called once, got %d", *calls)
+	}
+
+	// A different flag value must not use the same cache entry
+	output, err := executeCommand(rootCmd, ShellCompRequestCmd, "child", "--ns", "other", "")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "call2")
+
+	// Clearing the cache invalidates all entries
+	if err := ClearCompletionCache(rootCmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	output, err = executeCommand(rootCmd, ShellCompRequestCmd, "child", "")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "call3")
+}
+
+func TestCachedCompletionsFlagAndArgs(t *testing.T) {
+	setupCompletionCache(t)
+	rootCmd := &Command{
+		Use: "root",
+		ValidArgsFunction: CachedCompletions(func(cmd *Command, args []string, toComplete string) ([]Completion, ShellCompDirective) {
+			return []Completion{"arg1"}, ShellCompDirectiveNoFileComp
+		}, time.Hour),
+		Run: emptyRun,
+	}
+	rootCmd.Flags().String("ns", "", "namespace")
+	assertNoErr(t, rootCmd.RegisterFlagCompletionFunc("ns", CachedCompletions(func(cmd *Command, args []string, toComplete string) ([]Completion, ShellCompDirective) {
+		return []Completion{"default"}, ShellCompDirectiveNoFileComp
+	}, time.Hour)))
+
+	output, err := executeCommand(rootCmd, ShellCompNoDescRequestCmd, "")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "arg1\n")
+
+	// The completions of the arguments must not be used for the flag
+	output, err = executeCommand(rootCmd, ShellCompNoDescRequestCmd, "--ns", "")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "default\n")
+	checkStringOmits(t, output, "arg1")
+}
+
+func TestCachedCompletionsExpired(t *testing.T) {
+	setupCompletionCache(t)
+	rootCmd, calls := countingCompletionCmd(time.Nanosecond)
+
+	for i := 0; i < 2; i++ {
+		if _, err := executeCommand(rootCmd, ShellCompRequestCmd, "child", ""); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if *calls != 2 {
+		t.Errorf("Expected the completion function to be called twice, got %d", *calls)
+	}
+}
+
+func TestCachedCompletionsDisabled(t *testing.T) {
+	setupCompletionCache(t)
+	rootCmd, calls := countingCompletionCmd(time.Hour)
+
+	os.Setenv("COBRA_COMPLETION_CACHE", "false")
+	defer os.Unsetenv("COBRA_COMPLETION_CACHE")
+
+	for i := 0; i < 2; i++ {
+		if _, err := executeCommand(rootCmd, ShellCompRequestCmd, "child", ""); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+	if *calls != 2 {
+		t.Errorf("Expected the completion function to be called twice, got %d", *calls)
+	}
+
+	// The program-specific variable has precedence over the global one
+	os.Setenv("ROOT_COMPLETION_CACHE", "true")
+	defer os.Unsetenv("ROOT_COMPLETION_CACHE")
+
+	for i := 0; i < 2; i++ {
+		if _, err := executeCommand(rootCmd, ShellCompRequestCmd, "child", ""); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+	if *calls != 3 {
+		t.Errorf("Expected the completion function to be called three times, got %d", *calls)
+	}
+}
+
+func TestCachedCompletionsError(t *testing.T) {
+	setupCompletionCache(t)
+	calls := 0
+	rootCmd := &Command{
+		Use: "root",
+		ValidArgsFunction: CachedCompletions(func(cmd *Command, args []string, toComplete string) ([]Completion, ShellCompDirective) {
+			calls++
+			return nil, ShellCompDirectiveError
+		}, time.Hour),
+		Run: emptyRun,
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := executeCommand(rootCmd, ShellCompRequestCmd, ""); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected errors not to be cached, got %d calls", calls)
+	}
+}
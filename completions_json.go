@@ -0,0 +1,88 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"strings"
+)
+
+// compJSONVersion is the version of the format produced by the __completeJSON command.
+// It must be incremented whenever a change to the format is not backwards-compatible.
+const compJSONVersion = 1
+
+// completionJSON is the document printed by the __completeJSON command.
+type completionJSON struct {
+	Version     int                     `json:"version"`
+	Command     string                  `json:"command"`
+	Completions []completionChoiceJSON  `json:"completions"`
+	ActiveHelp  []string                `json:"activeHelp"`
+	Directive   completionDirectiveJSON `json:"directive"`
+	Error       string                  `json:"error,omitempty"`
+}
+
+type completionChoiceJSON struct {
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+// completionDirectiveJSON is a ShellCompDirective decoded into its different behaviors.
+type completionDirectiveJSON struct {
+	Value         ShellCompDirective `json:"value"`
+	Error         bool               `json:"error"`
+	NoSpace       bool               `json:"noSpace"`
+	NoFileComp    bool               `json:"noFileComp"`
+	FilterFileExt bool               `json:"filterFileExt"`
+	FilterDirs    bool               `json:"filterDirs"`
+	KeepOrder     bool               `json:"keepOrder"`
+}
+
+func newCompletionJSON(finalCmd *Command, completions []Completion, directive ShellCompDirective, err error, noDescriptions, noActiveHelp bool) completionJSON {
+	result := completionJSON{
+		Version:     compJSONVersion,
+		Command:     finalCmd.CommandPath(),
+		Completions: []completionChoiceJSON{},
+		ActiveHelp:  []string{},
+		Directive: completionDirectiveJSON{
+			Value:         directive,
+			Error:         directive&ShellCompDirectiveError != 0,
+			NoSpace:       directive&ShellCompDirectiveNoSpace != 0,
+			NoFileComp:    directive&ShellCompDirectiveNoFileComp != 0,
+			FilterFileExt: directive&ShellCompDirectiveFilterFileExt != 0,
+			FilterDirs:    directive&ShellCompDirectiveFilterDirs != 0,
+			KeepOrder:     directive&ShellCompDirectiveKeepOrder != 0,
+		},
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	for _, comp := range completions {
+		if strings.HasPrefix(comp, activeHelpMarker) {
+			if !noActiveHelp {
+				result.ActiveHelp = append(result.ActiveHelp, strings.TrimPrefix(comp, activeHelpMarker))
+			}
+			continue
+		}
+
+		choice := strings.SplitN(comp, "\t", 2)
+		value := completionChoiceJSON{Value: strings.TrimSpace(choice[0])}
+		if len(choice) > 1 && !noDescriptions {
+			// Unlike with shells, a description can span multiple lines
+			value.Description = strings.TrimSpace(choice[1])
+		}
+		result.Completions = append(result.Completions, value)
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func executeJSONCompletion(t *testing.T, root *Command, args ...string) completionJSON {
+	buf := new(bytes.Buffer)
+	root.SetOut(buf)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs(append([]string{ShellCompJSONRequestCmd}, args...))
+	if err := root.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var result completionJSON
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", buf.String(), err)
+	}
+	return result
+}
+
+func TestCompleteJSON(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{
+		Use: "child",
+		ValidArgsFunction: func(cmd *Command, args []string, toComplete string) ([]Completion, ShellCompDirective) {
+			comps := []Completion{CompletionWithDesc("one", "the first"), "two"}
+			comps = AppendActiveHelp(comps, "pick a number")
+			return comps, ShellCompDirectiveNoFileComp | ShellCompDirectiveKeepOrder
+		},
+		Run: emptyRun,
+	}
+	rootCmd.AddCommand(childCmd)
+
+	result := executeJSONCompletion(t, rootCmd, "child", "")
+
+	expected := completionJSON{
+		Version: compJSONVersion,
+		Command: "root child",
+		Completions: []completionChoiceJSON{
+			{Value: "one", Description: "the first"},
+			{Value: "two"},
+		},
+		ActiveHelp: []string{"pick a number"},
+		Directive: completionDirectiveJSON{
+			Value:      ShellCompDirectiveNoFileComp | ShellCompDirectiveKeepOrder,
+			NoFileComp: true,
+			KeepOrder:  true,
+		},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected: %+v\ngot: %+v", expected, result)
+	}
+}
+
+func TestCompleteJSONError(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(&Command{Use: "child", Run: emptyRun})
+
+	result := executeJSONCompletion(t, rootCmd, "child", "--unknown", "")
+
+	if result.Error == "" {
+		t.Error("Expected an error to be reported")
+	}
+	if result.Command != "root child" {
+		t.Errorf("Expected command %q, got %q", "root child", result.Command)
+	}
+	if result.Completions == nil || len(result.Completions) != 0 {
+		t.Errorf("Expected an empty list of completions, got %v", result.Completions)
+	}
+}
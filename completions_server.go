@@ -0,0 +1,218 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ShellCompServerRequestCmd is the name of the hidden command that starts a completion
+	// server answering JSON-RPC requests on stdin and stdout.  See ServeCompletions.
+	ShellCompServerRequestCmd = "__completeServer"
+
+	// CompletionServerMethodComplete is the JSON-RPC method used to request completions.
+	CompletionServerMethodComplete = "complete"
+	// CompletionServerMethodShutdown is the JSON-RPC method used to stop the completion server.
+	CompletionServerMethodShutdown = "shutdown"
+
+	// Error codes defined by the JSON-RPC 2.0 specification.
+	compRPCParseError     = -32700
+	compRPCInvalidRequest = -32600
+	compRPCMethodNotFound = -32601
+	compRPCInvalidParams  = -32602
+
+	// compRPCMaxMessageSize is the maximum size of a single request.
+	compRPCMaxMessageSize = 1024 * 1024
+)
+
+type compRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type compRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *compRPCError   `json:"error,omitempty"`
+}
+
+type compRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// compRPCCompleteParams are the parameters of the "complete" method.
+type compRPCCompleteParams struct {
+	// Args is the command-line following the program name, as it would be passed
+	// to the __complete command: the last element is the word being completed.
+	Args           []string `json:"args"`
+	NoDescriptions bool     `json:"noDescriptions"`
+}
+
+// ServeCompletions answers completion requests read from in until the end of the input
+// or until a "shutdown" request is received.  The responses are written to out.
+//
+// Each request and each response is a JSON-RPC 2.0 message on a single line.
+// The "complete" method accepts the command-line to complete as the "args" parameter
+// and returns the same document as the __completeJSON command.  This allows a single
+// long-lived process to serve the completions of an editor or terminal, instead of
+// running the program for every completion request.
+func (c *Command) ServeCompletions(in io.Reader, out io.Writer) error {
+	root := c.Root()
+	encoder := json.NewEncoder(out)
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), compRPCMaxMessageSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		response, shutdown := root.handleCompletionRequest([]byte(line))
+		if response != nil {
+			if err := encoder.Encode(response); err != nil {
+				return err
+			}
+		}
+		if shutdown {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// handleCompletionRequest processes a single JSON-RPC message.  It returns the response
+// to send, which is nil for notifications, and whether the server must stop.
+func (c *Command) handleCompletionRequest(message []byte) (*compRPCResponse, bool) {
+	var req compRPCRequest
+	if err := json.Unmarshal(message, &req); err != nil {
+		return newCompRPCError(nil, compRPCParseError, err.Error()), false
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return newCompRPCError(req.ID, compRPCInvalidRequest, "invalid JSON-RPC 2.0 request"), false
+	}
+	// Requests without an id are notifications, which must not be answered
+	isNotification := len(req.ID) == 0
+
+	var response *compRPCResponse
+	shutdown := false
+	switch req.Method {
+	case CompletionServerMethodComplete:
+		var params compRPCCompleteParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				response = newCompRPCError(req.ID, compRPCInvalidParams, err.Error())
+				break
+			}
+		}
+		response = &compRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: c.serveCompletion(params)}
+	case CompletionServerMethodShutdown:
+		response = &compRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage("null")}
+		shutdown = true
+	default:
+		response = newCompRPCError(req.ID, compRPCMethodNotFound, fmt.Sprintf("method not found: %s", req.Method))
+	}
+
+	if isNotification {
+		return nil, shutdown
+	}
+	return response, shutdown
+}
+
+func newCompRPCError(id json.RawMessage, code int, message string) *compRPCResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &compRPCResponse{JSONRPC: "2.0", ID: id, Error: &compRPCError{Code: code, Message: message}}
+}
+
+// serveCompletion computes the completions for a single request of the completion server.
+func (c *Command) serveCompletion(params compRPCCompleteParams) completionJSON {
+	args := params.Args
+	if len(args) == 0 {
+		// Completing right after the program name
+		args = []string{""}
+	}
+
+	// The values of the flags parsed for a previous request must not
+	// leak into this one, so start from the default values.
+	resetFlagValues(c)
+
+	// getCompletions must be called on the __complete command, which it may
+	// remove from the tree; make sure it is never left behind.
+	completeCmd := &Command{
+		Use:                ShellCompRequestCmd,
+		Hidden:             true,
+		DisableFlagParsing: true,
+	}
+	completeCmd.ctx = c.ctx
+	if completeCmd.ctx == nil {
+		completeCmd.ctx = context.Background()
+	}
+	c.AddCommand(completeCmd)
+	finalCmd, completions, directive, err := completeCmd.getCompletions(args)
+	c.RemoveCommand(completeCmd)
+
+	noDescriptions := params.NoDescriptions
+	if !noDescriptions {
+		if doDescriptions, err := strconv.ParseBool(getEnvConfig(c, configEnvVarSuffixDescriptions)); err == nil {
+			noDescriptions = !doDescriptions
+		}
+	}
+	if finalCmd == completeCmd {
+		// The real command could not be found
+		finalCmd = c
+	}
+	noActiveHelp := GetActiveHelpConfig(finalCmd) == activeHelpGlobalDisable
+	return newCompletionJSON(finalCmd, completions, directive, err, noDescriptions, noActiveHelp)
+}
+
+// initCompleteServerCmd adds a special hidden command that serves completion requests over stdio.
+func (c *Command) initCompleteServerCmd(args []string) {
+	serverCmd := &Command{
+		Use:                   ShellCompServerRequestCmd,
+		DisableFlagsInUseLine: true,
+		Hidden:                true,
+		Args:                  NoArgs,
+		Short:                 "Serve completion requests over stdio",
+		Long: fmt.Sprintf("%[2]s is a special command that starts a server answering\n%[1]s",
+			"JSON-RPC completion requests read from stdin.", ShellCompServerRequestCmd),
+		RunE: func(cmd *Command, args []string) error {
+			root := cmd.Root()
+			in, out := cmd.InOrStdin(), cmd.OutOrStdout()
+			// The server must not be part of the completions it serves
+			root.RemoveCommand(cmd)
+			return root.ServeCompletions(in, out)
+		},
+	}
+	c.AddCommand(serverCmd)
+	subCmd, _, err := c.Find(args)
+	if err != nil || subCmd.Name() != ShellCompServerRequestCmd {
+		// Only create this special command if it is actually being called,
+		// for the same reasons as for the __complete command.
+		c.RemoveCommand(serverCmd)
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type testCompRPCResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result completionJSON  `json:"result"`
+	Error  *compRPCError   `json:"error"`
+}
+
+func readCompRPCResponses(t *testing.T, output string) []testCompRPCResponse {
+	var responses []testCompRPCResponse
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var response testCompRPCResponse
+		if err := json.Unmarshal([]byte(line), &response); err != nil {
+			t.Fatalf("Invalid response %q: %v", line, err)
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+func completionValues(result completionJSON) []string {
+	values := []string{}
+	for _, comp := range result.Completions {
+		values = append(values, comp.Value)
+	}
+	return values
+}
+
+func TestServeCompletions(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{
+		Use: "child",
+		ValidArgsFunction: func(cmd *Command, args []string, toComplete string) ([]Completion, ShellCompDirective) {
+			name, _ := cmd.Flags().GetString("name")
+			return []Completion{"name=" + name}, ShellCompDirectiveNoFileComp
+		},
+		Run: emptyRun,
+	}
+	childCmd.Flags().String("name", "default", "the name")
+	rootCmd.AddCommand(childCmd)
+
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"complete","params":{"args":["ch"]}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"complete","params":{"args":["child","--name","foo",""]}}`,
+		`{"jsonrpc":"2.0","method":"complete","params":{"args":[""]}}`,
+		``,
+		`{"jsonrpc":"2.0","id":3,"method":"complete","params":{"args":["child",""]}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"complete","params":{"args":[""]}}`,
+	}, "\n")
+	out := new(bytes.Buffer)
+	if err := rootCmd.ServeCompletions(strings.NewReader(in), out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	responses := readCompRPCResponses(t, out.String())
+	if len(responses) != 4 {
+		t.Fatalf("Expected 4 responses, got %d: %s", len(responses), out.String())
+	}
+
+	expected := []string{"child"}
+	if got := completionValues(responses[0].Result); strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected: %v, got: %v", expected, got)
+	}
+	expected = []string{"name=foo"}
+	if got := completionValues(responses[1].Result); strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected: %v, got: %v", expected, got)
+	}
+	// The flag value of the previous request must not be reused
+	expected = []string{"name=default"}
+	if got := completionValues(responses[2].Result); strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected: %v, got: %v", expected, got)
+	}
+	if responses[2].Result.Command != "root child" {
+		t.Errorf("Expected command %q, got %q", "root child", responses[2].Result.Command)
+	}
+	if string(responses[3].ID) != "4" || responses[3].Error != nil {
+		t.Errorf("Unexpected response to shutdown: %+v", responses[3])
+	}
+
+	// The server must not leave its commands behind
+	if len(rootCmd.Commands()) != 1 {
+		t.Errorf("Expected only the child command, got %v", rootCmd.Commands())
+	}
+}
+
+func TestServeCompletionsFlagGroups(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.Flags().Bool("a", false, "")
+	rootCmd.Flags().Bool("b", false, "")
+	rootCmd.Flags().String("c", "", "")
+	rootCmd.Flags().String("d", "", "")
+	rootCmd.MarkFlagsMutuallyExclusive("a", "b")
+	rootCmd.MarkFlagsRequiredTogether("c", "d")
+
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"complete","params":{"args":["--a","--"]}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"complete","params":{"args":["--c","x","--"]}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"complete","params":{"args":["--"]}}`,
+	}, "\n")
+	out := new(bytes.Buffer)
+	if err := rootCmd.ServeCompletions(strings.NewReader(in), out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	responses := readCompRPCResponses(t, out.String())
+	if len(responses) != 3 {
+		t.Fatalf("Expected 3 responses, got %d: %s", len(responses), out.String())
+	}
+	// The flags hidden or required by the groups of a request must not leak into the next ones
+	for i, expected := range []string{"--c --d --help", "--d", "--a --b --c --d --help"} {
+		if got := completionValues(responses[i].Result); strings.Join(got, " ") != expected {
+			t.Errorf("Request %d: expected: %v, got: %v", i+1, expected, got)
+		}
+	}
+
+	rootCmd.ResetState()
+	if _, err := executeCommand(rootCmd); err != nil {
+		t.Errorf("Expected the flags not to be required after the completion, got %v", err)
+	}
+}
+
+func TestServeCompletionsErrors(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+
+	in := strings.Join([]string{
+		`not json`,
+		`{"jsonrpc":"2.0","id":"a","method":"unknown"}`,
+		`{"jsonrpc":"2.0","id":"b","method":"complete","params":{"args":"wrong"}}`,
+		`{"id":"c","method":"complete"}`,
+	}, "\n")
+	out := new(bytes.Buffer)
+	if err := rootCmd.ServeCompletions(strings.NewReader(in), out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	responses := readCompRPCResponses(t, out.String())
+	expectedCodes := []int{compRPCParseError, compRPCMethodNotFound, compRPCInvalidParams, compRPCInvalidRequest}
+	if len(responses) != len(expectedCodes) {
+		t.Fatalf("Expected %d responses, got %d: %s", len(expectedCodes), len(responses), out.String())
+	}
+	for i, code := range expectedCodes {
+		if responses[i].Error == nil || responses[i].Error.Code != code {
+			t.Errorf("Expected error code %d for response %d, got %+v", code, i, responses[i])
+		}
+	}
+	if string(responses[0].ID) != "null" {
+		t.Errorf("Expected a null id, got %s", responses[0].ID)
+	}
+}
+
+func TestCompleteServerCmd(t *testing.T) {
+	rootCmd := &Command{Use: "root", ValidArgs: []string{"one", "two"}, Run: emptyRun}
+
+	in := `{"jsonrpc":"2.0","id":1,"method":"complete","params":{"args":[""]}}` + "\n"
+	rootCmd.SetIn(strings.NewReader(in))
+	output, err := executeCommand(rootCmd, ShellCompServerRequestCmd)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	responses := readCompRPCResponses(t, output)
+	expected := []string{"one", "two"}
+	if got := completionValues(responses[0].Result); strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected: %v, got: %v", expected, got)
+	}
+
+	// The root command must still accept arguments
+	if _, err := executeCommand(rootCmd, "one"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
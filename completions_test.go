@@ -2545,7 +2545,7 @@ func TestDefaultCompletionCmd(t *testing.T) {
 	var compCmd *Command
 	// Test that the --no-descriptions flag is present on all shells
 	assertNoErr(t, rootCmd.Execute())
-	for _, shell := range []string{"bash", "fish", "powershell", "zsh"} {
+	for _, shell := range []string{"bash", "elvish", "fish", "nushell", "powershell", "xonsh", "zsh"} {
 		if compCmd, _, err = rootCmd.Find([]string{compCmdName, shell}); err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
@@ -2559,7 +2559,7 @@ func TestDefaultCompletionCmd(t *testing.T) {
 	// Test that the '--no-descriptions' flag can be disabled
 	rootCmd.CompletionOptions.DisableNoDescFlag = true
 	assertNoErr(t, rootCmd.Execute())
-	for _, shell := range []string{"fish", "zsh", "bash", "powershell"} {
+	for _, shell := range []string{"fish", "zsh", "bash", "powershell", "nushell", "elvish", "xonsh"} {
 		if compCmd, _, err = rootCmd.Find([]string{compCmdName, shell}); err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
@@ -2575,7 +2575,7 @@ func TestDefaultCompletionCmd(t *testing.T) {
 	// Test that the '--no-descriptions' flag is disabled when descriptions are disabled
 	rootCmd.CompletionOptions.DisableDescriptions = true
 	assertNoErr(t, rootCmd.Execute())
-	for _, shell := range []string{"fish", "zsh", "bash", "powershell"} {
+	for _, shell := range []string{"fish", "zsh", "bash", "powershell", "nushell", "elvish", "xonsh"} {
 		if compCmd, _, err = rootCmd.Find([]string{compCmdName, shell}); err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
@@ -2631,8 +2631,11 @@ func TestCompleteCompletion(t *testing.T) {
 
 	expected = strings.Join([]string{
 		"bash",
+		"elvish",
 		"fish",
+		"nushell",
 		"powershell",
+		"xonsh",
 		"zsh",
 		":4",
 		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
@@ -2656,8 +2659,11 @@ func TestCompleteCompletion(t *testing.T) {
 
 	expected = strings.Join([]string{
 		"bash",
+		"elvish",
 		"fish",
+		"nushell",
 		"powershell",
+		"xonsh",
 		"zsh",
 		":4",
 		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
@@ -3346,6 +3352,107 @@ func TestCompletionForMutuallyExclusiveFlags(t *testing.T) {
 	}
 }
 
+func TestCompletionForExactlyOneAndRequiresFlags(t *testing.T) {
+	getCmd := func() *Command {
+		rootCmd := &Command{
+			Use: "root",
+			Run: emptyRun,
+		}
+		childCmd := &Command{
+			Use: "child",
+			ValidArgsFunction: func(cmd *Command, args []string, toComplete string) ([]string, ShellCompDirective) {
+				return []string{"subArg"}, ShellCompDirectiveNoFileComp
+			},
+			Run: emptyRun,
+		}
+		rootCmd.AddCommand(childCmd)
+
+		childCmd.Flags().String("ingroup1", "", "ingroup1")
+		childCmd.Flags().String("ingroup2", "", "ingroup2")
+		childCmd.Flags().Bool("tls", false, "tls")
+		childCmd.Flags().String("cert", "", "cert")
+		childCmd.Flags().String("output", "", "output")
+		childCmd.Flags().String("format", "", "format")
+
+		childCmd.MarkFlagsExactlyOne("ingroup1", "ingroup2")
+		childCmd.MarkFlagRequires("tls", "cert")
+		childCmd.MarkFlagRequiresIf("output", "file", "format=json")
+
+		return rootCmd
+	}
+
+	// Each test case uses a unique command from the function above.
+	testcases := []struct {
+		desc           string
+		args           []string
+		expectedOutput string
+	}{
+		{
+			desc: "flags of an exactly-one group suggested when none is present",
+			args: []string{"child", ""},
+			expectedOutput: strings.Join([]string{
+				"--ingroup1",
+				"--ingroup2",
+				"subArg",
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
+		},
+		{
+			desc: "other flags of an exactly-one group not suggested when one is present",
+			args: []string{"child", "--ingroup1", "value", "--"},
+			expectedOutput: strings.Join([]string{
+				"--cert",
+				"--format",
+				"--help",
+				"--output",
+				"--tls",
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
+		},
+		{
+			desc: "required flag suggested when the constraint applies",
+			args: []string{"child", "--ingroup1", "value", "--tls", ""},
+			expectedOutput: strings.Join([]string{
+				"--cert",
+				"subArg",
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
+		},
+		{
+			desc: "required flag suggested when the conditional constraint applies",
+			args: []string{"child", "--ingroup1", "value", "--output", "file", ""},
+			expectedOutput: strings.Join([]string{
+				"--format",
+				"subArg",
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
+		},
+		{
+			desc: "required flag not suggested when the conditional constraint does not apply",
+			args: []string{"child", "--ingroup1", "value", "--output", "stdout", ""},
+			expectedOutput: strings.Join([]string{
+				"subArg",
+				":4",
+				"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n"),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := getCmd()
+			args := []string{ShellCompNoDescRequestCmd}
+			args = append(args, tc.args...)
+			output, err := executeCommand(c, args...)
+			switch {
+			case err == nil && output != tc.expectedOutput:
+				t.Errorf("expected: %q, got: %q", tc.expectedOutput, output)
+			case err != nil:
+				t.Errorf("Unexpected error %q", err)
+			}
+		})
+	}
+}
+
 func TestCompletionCobraFlags(t *testing.T) {
 	getCmd := func() *Command {
 		rootCmd := &Command{
@@ -4127,3 +4234,89 @@ func TestCompletionDoesNotMutateOsArgs(t *testing.T) {
 		t.Errorf("os.Args[2] was mutated: expected %q, got %q", "x", os.Args[2])
 	}
 }
+
+func TestArgCompletionFuncs(t *testing.T) {
+	rootCmd := &Command{Use: "root", Args: NoArgs, Run: emptyRun}
+	childCmd := &Command{
+		Use: "child",
+		ValidArgsFunction: func(cmd *Command, args []string, toComplete string) ([]Completion, ShellCompDirective) {
+			return []Completion{"fromValidArgsFunction"}, ShellCompDirectiveNoFileComp
+		},
+		Run: emptyRun,
+	}
+	rootCmd.AddCommand(childCmd)
+
+	assertNoErr(t, childCmd.RegisterArgCompletionFunc(0, FixedCompletions([]Completion{"first"}, ShellCompDirectiveNoFileComp)))
+	assertNoErr(t, childCmd.RegisterArgCompletionFunc(2, FileCompletions("yaml", "json")))
+
+	testcases := []struct {
+		desc     string
+		args     []string
+		expected []string
+	}{
+		{
+			desc:     "first argument",
+			args:     []string{"child", ""},
+			expected: []string{"first", ":4", "Completion ended with directive: ShellCompDirectiveNoFileComp"},
+		},
+		{
+			desc:     "argument without a function",
+			args:     []string{"child", "a", ""},
+			expected: []string{"fromValidArgsFunction", ":4", "Completion ended with directive: ShellCompDirectiveNoFileComp"},
+		},
+		{
+			desc:     "file extensions",
+			args:     []string{"child", "a", "b", ""},
+			expected: []string{"yaml", "json", ":8", "Completion ended with directive: ShellCompDirectiveFilterFileExt"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			output, err := executeCommand(rootCmd, append([]string{ShellCompNoDescRequestCmd}, tc.args...)...)
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			expected := strings.Join(append(tc.expected, ""), "\n")
+			if output != expected {
+				t.Errorf("expected: %q, got: %q", expected, output)
+			}
+		})
+	}
+
+	// The rest function replaces ValidArgsFunction for the arguments without their own function
+	assertNoErr(t, childCmd.RegisterRestArgsCompletionFunc(DirCompletions("themes")))
+	output, err := executeCommand(rootCmd, ShellCompNoDescRequestCmd, "child", "a", "b", "c", "")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	expected := strings.Join([]string{
+		"themes",
+		":16",
+		"Completion ended with directive: ShellCompDirectiveFilterDirs", ""}, "\n")
+	if output != expected {
+		t.Errorf("expected: %q, got: %q", expected, output)
+	}
+}
+
+func TestRegisterArgCompletionFuncErrors(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+
+	if err := c.RegisterArgCompletionFunc(-1, NoFileCompletions); err == nil {
+		t.Error("Expected an error for a negative index")
+	}
+	assertNoErr(t, c.RegisterArgCompletionFunc(1, NoFileCompletions))
+	if err := c.RegisterArgCompletionFunc(1, NoFileCompletions); err == nil {
+		t.Error("Expected an error when registering the same index twice")
+	}
+	assertNoErr(t, c.RegisterRestArgsCompletionFunc(NoFileCompletions))
+	if err := c.RegisterRestArgsCompletionFunc(NoFileCompletions); err == nil {
+		t.Error("Expected an error when registering the rest function twice")
+	}
+
+	if _, exists := c.GetArgCompletionFunc(0); !exists {
+		t.Error("Expected the rest function to be returned for index 0")
+	}
+	if _, exists := c.GetArgCompletionFunc(-1); exists {
+		t.Error("Expected no function for a negative index")
+	}
+}
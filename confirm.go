@@ -0,0 +1,88 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+const confirmFlagName = "yes"
+
+// ConfirmationError is returned when a command with Confirm set is not confirmed.
+type ConfirmationError struct {
+	// CommandPath is the path of the command which was not run.
+	CommandPath string
+	// Declined is true if the user answered no, and false if the input is not
+	// a terminal and the --yes flag was not set.
+	Declined bool
+}
+
+func (e *ConfirmationError) Error() string {
+	if e.Declined {
+		return fmt.Sprintf("%q was not confirmed", e.CommandPath)
+	}
+	return fmt.Sprintf("%q requires confirmation, use --%s to run it non-interactively", e.CommandPath, confirmFlagName)
+}
+
+// InitDefaultConfirmFlag adds the --yes flag to c if it requires confirmation.
+// It is called automatically by executing the c, and when generating its help and documentation.
+// If c already has a flag named yes, it will do nothing.
+func (c *Command) InitDefaultConfirmFlag() {
+	if c.Confirm == "" {
+		return
+	}
+
+	c.mergePersistentFlags()
+	if c.Flags().Lookup(confirmFlagName) == nil {
+		usage := "confirm running "
+		if c.Name() == "" {
+			usage += "this command"
+		} else {
+			usage += c.DisplayName()
+		}
+		usage += " without being prompted"
+		if c.Flags().ShorthandLookup("y") == nil {
+			c.Flags().BoolP(confirmFlagName, "y", false, usage)
+		} else {
+			c.Flags().Bool(confirmFlagName, false, usage)
+		}
+		_ = c.Flags().SetAnnotation(confirmFlagName, FlagSetByCobraAnnotation, []string{"true"})
+	}
+}
+
+// confirm asks the user to confirm running the command, unless it does not require
+// confirmation or the --yes flag is set.
+func (c *Command) confirm() error {
+	if c.Confirm == "" {
+		return nil
+	}
+	if yes, err := c.Flags().GetBool(confirmFlagName); err == nil && yes {
+		return nil
+	}
+
+	in := c.InOrStdin()
+	if !isInteractive(in) {
+		return usageError(&ConfirmationError{CommandPath: c.CommandPath()})
+	}
+	fmt.Fprintf(c.ErrOrStderr(), "%s [y/N]: ", c.Confirm)
+	answer, _ := bufio.NewReader(byteReader{in}).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return &ConfirmationError{CommandPath: c.CommandPath(), Declined: true}
+}
@@ -0,0 +1,159 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestConfirmAnswers(t *testing.T) {
+	interactive(t)
+	testcases := []struct {
+		input string
+		ran   bool
+	}{
+		{input: "y\n", ran: true},
+		{input: " YES \n", ran: true},
+		{input: "n\n", ran: false},
+		{input: "\n", ran: false},
+		{input: "", ran: false},
+	}
+	for _, tc := range testcases {
+		var ran bool
+		rootCmd := &Command{Use: "root"}
+		rootCmd.AddCommand(&Command{
+			Use:     "delete",
+			Args:    ExactArgs(1),
+			Confirm: "Delete the release?",
+			Run:     func(*Command, []string) { ran = true },
+		})
+
+		output, err := executePromptCommand(rootCmd, tc.input, "delete", "v1")
+		checkStringContains(t, output, "Delete the release? [y/N]: ")
+		if ran != tc.ran {
+			t.Errorf("%q: expected the command to run: %v", tc.input, tc.ran)
+		}
+		var confirmErr *ConfirmationError
+		if tc.ran && err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.input, err)
+		}
+		if !tc.ran && (!errors.As(err, &confirmErr) || !confirmErr.Declined || ExitCode(err) != ExitCodeError) {
+			t.Errorf("%q: expected a declined confirmation, got %v", tc.input, err)
+		}
+	}
+}
+
+func TestConfirmLeavesInput(t *testing.T) {
+	interactive(t)
+	var rest string
+	rootCmd := &Command{Use: "root"}
+	rootCmd.AddCommand(&Command{
+		Use:     "delete",
+		Confirm: "Delete the release?",
+		Run: func(cmd *Command, args []string) {
+			data, _ := io.ReadAll(cmd.InOrStdin())
+			rest = string(data)
+		},
+	})
+
+	if _, err := executePromptCommand(rootCmd, "y\nthe input of the command\n", "delete"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "the input of the command\n"; rest != expected {
+		t.Errorf("Expected the command to read %q, got %q", expected, rest)
+	}
+}
+
+func TestConfirmYesFlag(t *testing.T) {
+	for _, flag := range []string{"--yes", "-y"} {
+		var ran bool
+		rootCmd := &Command{Use: "root"}
+		rootCmd.AddCommand(&Command{
+			Use:     "delete",
+			Args:    ExactArgs(1),
+			Confirm: "Delete the release?",
+			Run:     func(*Command, []string) { ran = true },
+		})
+
+		output, err := executePromptCommand(rootCmd, "", "delete", "v1", flag)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !ran || output != "" {
+			t.Errorf("Expected %s to run the command without prompting, got %q", flag, output)
+		}
+	}
+}
+
+func TestConfirmNonInteractive(t *testing.T) {
+	var ran bool
+	rootCmd := &Command{Use: "root"}
+	rootCmd.AddCommand(&Command{
+		Use:     "delete",
+		Args:    ExactArgs(1),
+		Confirm: "Delete the release?",
+		Run:     func(*Command, []string) { ran = true },
+	})
+
+	output, err := executePromptCommand(rootCmd, "y\n", "delete", "v1")
+	var confirmErr *ConfirmationError
+	if !errors.As(err, &confirmErr) || confirmErr.Declined || ExitCode(err) != ExitCodeUsage {
+		t.Errorf("Expected a usage error requiring confirmation, got %v", err)
+	}
+	if ran {
+		t.Error("Expected the command not to run")
+	}
+	checkStringContains(t, output, `Error: "root delete" requires confirmation, use --yes to run it non-interactively`)
+}
+
+func TestConfirmAfterValidation(t *testing.T) {
+	interactive(t)
+	rootCmd := &Command{Use: "root"}
+	rootCmd.AddCommand(&Command{Use: "delete", Args: ExactArgs(1), Confirm: "Delete the release?", Run: emptyRun})
+
+	output, err := executePromptCommand(rootCmd, "y\n", "delete")
+	var countErr *InvalidArgCountError
+	if !errors.As(err, &countErr) {
+		t.Errorf("Expected the argument error, got %v", err)
+	}
+	checkStringOmits(t, output, "Delete the release?")
+}
+
+func TestConfirmFlagInHelp(t *testing.T) {
+	rootCmd := &Command{Use: "root"}
+	rootCmd.AddCommand(&Command{Use: "delete", Confirm: "Delete the release?", Run: emptyRun})
+
+	output, err := executeCommand(rootCmd, "help", "delete")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "-y, --yes    confirm running delete without being prompted")
+}
+
+func TestConfirmUserDefinedShorthand(t *testing.T) {
+	rootCmd := &Command{Use: "root"}
+	deleteCmd := &Command{Use: "delete", Args: ExactArgs(1), Confirm: "Delete the release?", Run: emptyRun}
+	deleteCmd.Flags().BoolP("yearly", "y", false, "")
+	rootCmd.AddCommand(deleteCmd)
+
+	if _, err := executePromptCommand(rootCmd, "", "delete", "v1", "--yes"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if f := deleteCmd.Flags().Lookup("yes"); f == nil || f.Shorthand != "" {
+		t.Errorf("Expected the --yes flag without shorthand, got %v", f)
+	}
+}
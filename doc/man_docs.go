@@ -50,6 +50,7 @@ func GenManTreeFromOpts(cmd *cobra.Command, opts GenManTreeOptions) error {
 	if header == nil {
 		header = &GenManHeader{}
 	}
+	cmd.BuildLazyCommands()
 	for _, c := range cmd.Commands() {
 		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
 			continue
@@ -184,14 +185,33 @@ func manPrintFlags(buf io.StringWriter, flags *pflag.FlagSet) {
 	})
 }
 
+func manPrintArgs(buf io.StringWriter, command *cobra.Command) {
+	if !command.HasArgDefs() {
+		return
+	}
+	cobra.WriteStringAndCheck(buf, "# ARGUMENTS\n")
+	for _, arg := range command.ArgDefs {
+		format := fmt.Sprintf("**%s**", arg.Name)
+		if arg.Type != "" && arg.Type != cobra.ArgTypeString {
+			format += fmt.Sprintf(" (%s)", arg.Type)
+		}
+		description := arg.Description
+		if arg.Type == cobra.ArgTypeEnum && len(arg.Values) > 0 {
+			description += fmt.Sprintf(" (one of %s)", strings.Join(arg.Values, ", "))
+		}
+		cobra.WriteStringAndCheck(buf, fmt.Sprintf("%s\n\t%s\n\n", format, strings.TrimSpace(description)))
+	}
+	cobra.WriteStringAndCheck(buf, "\n")
+}
+
 func manPrintOptions(buf io.StringWriter, command *cobra.Command) {
-	flags := command.NonInheritedFlags()
+	flags := command.FlagsWithEnvUsage(command.NonInheritedFlags())
 	if flags.HasAvailableFlags() {
 		cobra.WriteStringAndCheck(buf, "# OPTIONS\n")
 		manPrintFlags(buf, flags)
 		cobra.WriteStringAndCheck(buf, "\n")
 	}
-	flags = command.InheritedFlags()
+	flags = command.FlagsWithEnvUsage(command.InheritedFlags())
 	if flags.HasAvailableFlags() {
 		cobra.WriteStringAndCheck(buf, "# OPTIONS INHERITED FROM PARENT COMMANDS\n")
 		manPrintFlags(buf, flags)
@@ -202,6 +222,7 @@ func manPrintOptions(buf io.StringWriter, command *cobra.Command) {
 func genMan(cmd *cobra.Command, header *GenManHeader) []byte {
 	cmd.InitDefaultHelpCmd()
 	cmd.InitDefaultHelpFlag()
+	cmd.InitDefaultConfirmFlag()
 
 	// something like `rootcmd-subcmd1-subcmd2`
 	dashCommandName := strings.ReplaceAll(cmd.CommandPath(), " ", "-")
@@ -209,6 +230,7 @@ func genMan(cmd *cobra.Command, header *GenManHeader) []byte {
 	buf := new(bytes.Buffer)
 
 	manPreamble(buf, header, cmd, dashCommandName)
+	manPrintArgs(buf, cmd)
 	manPrintOptions(buf, cmd)
 	if len(cmd.Example) > 0 {
 		buf.WriteString("# EXAMPLE\n")
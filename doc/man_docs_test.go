@@ -66,6 +66,25 @@ func TestGenManDoc(t *testing.T) {
 	checkStringContains(t, output, translate("Auto generated"))
 }
 
+func TestGenManDocWithArgDefs(t *testing.T) {
+	c := &cobra.Command{
+		Use: "deploy",
+		ArgDefs: []cobra.ArgDef{
+			{Name: "REPLICAS", Description: "the number of replicas", Type: cobra.ArgTypeInt},
+		},
+		Run: emptyRun,
+	}
+
+	buf := new(bytes.Buffer)
+	if err := GenMan(c, nil, buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	checkStringContains(t, output, ".SH ARGUMENTS")
+	checkStringContains(t, output, "\\fBREPLICAS\\fP (int)\n\tthe number of replicas")
+}
+
 func TestGenManNoHiddenParents(t *testing.T) {
 	header := &GenManHeader{
 		Title:   "Project",
@@ -30,7 +30,7 @@ import (
 const markdownExtension = ".md"
 
 func printOptions(buf *bytes.Buffer, cmd *cobra.Command, name string) error {
-	flags := cmd.NonInheritedFlags()
+	flags := cmd.FlagsWithEnvUsage(cmd.NonInheritedFlags())
 	flags.SetOutput(buf)
 	if flags.HasAvailableFlags() {
 		buf.WriteString("### Options\n\n```\n")
@@ -38,7 +38,7 @@ func printOptions(buf *bytes.Buffer, cmd *cobra.Command, name string) error {
 		buf.WriteString("```\n\n")
 	}
 
-	parentFlags := cmd.InheritedFlags()
+	parentFlags := cmd.FlagsWithEnvUsage(cmd.InheritedFlags())
 	parentFlags.SetOutput(buf)
 	if parentFlags.HasAvailableFlags() {
 		buf.WriteString("### Options inherited from parent commands\n\n```\n")
@@ -57,6 +57,7 @@ func GenMarkdown(cmd *cobra.Command, w io.Writer) error {
 func GenMarkdownCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string) string) error {
 	cmd.InitDefaultHelpCmd()
 	cmd.InitDefaultHelpFlag()
+	cmd.InitDefaultConfirmFlag()
 
 	buf := new(bytes.Buffer)
 	name := cmd.CommandPath()
@@ -77,6 +78,11 @@ func GenMarkdownCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string)
 		fmt.Fprintf(buf, "```\n%s\n```\n\n", cmd.Example)
 	}
 
+	if cmd.HasArgDefs() {
+		buf.WriteString("### Arguments\n\n")
+		fmt.Fprintf(buf, "```\n%s```\n\n", cmd.ArgDefUsages())
+	}
+
 	if err := printOptions(buf, cmd, name); err != nil {
 		return err
 	}
@@ -131,6 +137,7 @@ func GenMarkdownTree(cmd *cobra.Command, dir string) error {
 // GenMarkdownTreeCustom is the same as GenMarkdownTree, but
 // with custom filePrepender and linkHandler.
 func GenMarkdownTreeCustom(cmd *cobra.Command, dir string, filePrepender, linkHandler func(string) string) error {
+	cmd.BuildLazyCommands()
 	for _, c := range cmd.Commands() {
 		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
 			continue
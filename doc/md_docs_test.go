@@ -55,6 +55,49 @@ func TestGenMdDocWithNoLongOrSynopsis(t *testing.T) {
 	checkStringOmits(t, output, "### Synopsis")
 }
 
+func TestGenMdDocWithFlagEnv(t *testing.T) {
+	c := &cobra.Command{Use: "envapp", EnvPrefix: "envapp", Run: emptyRun}
+	c.Flags().String("region", "", "the region")
+
+	buf := new(bytes.Buffer)
+	if err := GenMarkdown(c, buf); err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, buf.String(), "the region (env $ENVAPP_REGION)")
+}
+
+func TestGenMdDocWithConfirm(t *testing.T) {
+	c := &cobra.Command{Use: "purge", Confirm: "Purge everything?", Run: emptyRun}
+
+	buf := new(bytes.Buffer)
+	if err := GenMarkdown(c, buf); err != nil {
+		t.Fatal(err)
+	}
+	checkStringContains(t, buf.String(), "-y, --yes    confirm running purge without being prompted")
+}
+
+func TestGenMdDocWithArgDefs(t *testing.T) {
+	c := &cobra.Command{
+		Use: "deploy",
+		ArgDefs: []cobra.ArgDef{
+			{Name: "ENV", Description: "the environment", Type: cobra.ArgTypeEnum, Values: []string{"dev", "prod"}},
+			{Name: "TIMEOUT", Description: "the timeout", Type: cobra.ArgTypeDuration, Optional: true},
+		},
+		Run: emptyRun,
+	}
+
+	buf := new(bytes.Buffer)
+	if err := GenMarkdown(c, buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	checkStringContains(t, output, "deploy ENV [TIMEOUT] [flags]")
+	checkStringContains(t, output, "### Arguments")
+	checkStringContains(t, output, "the environment (one of dev, prod)")
+	checkStringContains(t, output, "[TIMEOUT] duration   the timeout")
+}
+
 func TestGenMdNoHiddenParents(t *testing.T) {
 	// We generate on subcommand so we have both subcommands and parents.
 	for _, name := range []string{"rootflag", "strtwo"} {
@@ -28,7 +28,7 @@ import (
 )
 
 func printOptionsReST(buf *bytes.Buffer, cmd *cobra.Command, name string) error {
-	flags := cmd.NonInheritedFlags()
+	flags := cmd.FlagsWithEnvUsage(cmd.NonInheritedFlags())
 	flags.SetOutput(buf)
 	if flags.HasAvailableFlags() {
 		buf.WriteString("Options\n")
@@ -37,7 +37,7 @@ func printOptionsReST(buf *bytes.Buffer, cmd *cobra.Command, name string) error
 		buf.WriteString("\n")
 	}
 
-	parentFlags := cmd.InheritedFlags()
+	parentFlags := cmd.FlagsWithEnvUsage(cmd.InheritedFlags())
 	parentFlags.SetOutput(buf)
 	if parentFlags.HasAvailableFlags() {
 		buf.WriteString("Options inherited from parent commands\n")
@@ -62,6 +62,7 @@ func GenReST(cmd *cobra.Command, w io.Writer) error {
 func GenReSTCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string, string) string) error {
 	cmd.InitDefaultHelpCmd()
 	cmd.InitDefaultHelpFlag()
+	cmd.InitDefaultConfirmFlag()
 
 	buf := new(bytes.Buffer)
 	name := cmd.CommandPath()
@@ -91,6 +92,12 @@ func GenReSTCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string, str
 		fmt.Fprintf(buf, "::\n\n%s\n\n", indentString(cmd.Example, "  "))
 	}
 
+	if cmd.HasArgDefs() {
+		buf.WriteString("Arguments\n")
+		buf.WriteString("~~~~~~~~~\n\n")
+		fmt.Fprintf(buf, "::\n\n%s\n", cmd.ArgDefUsages())
+	}
+
 	if err := printOptionsReST(buf, cmd, name); err != nil {
 		return err
 	}
@@ -143,6 +150,7 @@ func GenReSTTree(cmd *cobra.Command, dir string) error {
 // GenReSTTreeCustom is the same as GenReSTTree, but
 // with custom filePrepender and linkHandler.
 func GenReSTTreeCustom(cmd *cobra.Command, dir string, filePrepender func(string) string, linkHandler func(string, string) string) error {
+	cmd.BuildLazyCommands()
 	for _, c := range cmd.Commands() {
 		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
 			continue
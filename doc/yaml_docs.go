@@ -34,15 +34,25 @@ type cmdOption struct {
 	Usage        string `yaml:",omitempty"`
 }
 
+type cmdArgument struct {
+	Name        string
+	Type        string   `yaml:",omitempty"`
+	Values      []string `yaml:",omitempty"`
+	Optional    bool     `yaml:",omitempty"`
+	Variadic    bool     `yaml:",omitempty"`
+	Description string   `yaml:",omitempty"`
+}
+
 type cmdDoc struct {
 	Name             string
-	Synopsis         string      `yaml:",omitempty"`
-	Description      string      `yaml:",omitempty"`
-	Usage            string      `yaml:",omitempty"`
-	Options          []cmdOption `yaml:",omitempty"`
-	InheritedOptions []cmdOption `yaml:"inherited_options,omitempty"`
-	Example          string      `yaml:",omitempty"`
-	SeeAlso          []string    `yaml:"see_also,omitempty"`
+	Synopsis         string        `yaml:",omitempty"`
+	Description      string        `yaml:",omitempty"`
+	Usage            string        `yaml:",omitempty"`
+	Arguments        []cmdArgument `yaml:",omitempty"`
+	Options          []cmdOption   `yaml:",omitempty"`
+	InheritedOptions []cmdOption   `yaml:"inherited_options,omitempty"`
+	Example          string        `yaml:",omitempty"`
+	SeeAlso          []string      `yaml:"see_also,omitempty"`
 }
 
 // GenYamlTree creates yaml structured ref files for this command and all descendants
@@ -58,6 +68,7 @@ func GenYamlTree(cmd *cobra.Command, dir string) error {
 
 // GenYamlTreeCustom creates yaml structured ref files.
 func GenYamlTreeCustom(cmd *cobra.Command, dir string, filePrepender, linkHandler func(string) string) error {
+	cmd.BuildLazyCommands()
 	for _, c := range cmd.Commands() {
 		if !c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand() {
 			continue
@@ -93,6 +104,7 @@ func GenYaml(cmd *cobra.Command, w io.Writer) error {
 func GenYamlCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string) string) error {
 	cmd.InitDefaultHelpCmd()
 	cmd.InitDefaultHelpFlag()
+	cmd.InitDefaultConfirmFlag()
 
 	yamlDoc := cmdDoc{}
 	yamlDoc.Name = cmd.CommandPath()
@@ -108,11 +120,22 @@ func GenYamlCustom(cmd *cobra.Command, w io.Writer, linkHandler func(string) str
 		yamlDoc.Example = cmd.Example
 	}
 
-	flags := cmd.NonInheritedFlags()
+	for _, arg := range cmd.ArgDefs {
+		yamlDoc.Arguments = append(yamlDoc.Arguments, cmdArgument{
+			Name:        arg.Name,
+			Type:        string(arg.Type),
+			Values:      arg.Values,
+			Optional:    arg.Optional,
+			Variadic:    arg.Variadic,
+			Description: arg.Description,
+		})
+	}
+
+	flags := cmd.FlagsWithEnvUsage(cmd.NonInheritedFlags())
 	if flags.HasFlags() {
 		yamlDoc.Options = genFlagResult(flags)
 	}
-	flags = cmd.InheritedFlags()
+	flags = cmd.FlagsWithEnvUsage(cmd.InheritedFlags())
 	if flags.HasFlags() {
 		yamlDoc.InheritedOptions = genFlagResult(flags)
 	}
@@ -41,6 +41,25 @@ func TestGenYamlDoc(t *testing.T) {
 	checkStringContains(t, output, fmt.Sprintf("- %s - %s", echoSubCmd.CommandPath(), echoSubCmd.Short))
 }
 
+func TestGenYamlDocWithArgDefs(t *testing.T) {
+	c := &cobra.Command{
+		Use: "copy",
+		ArgDefs: []cobra.ArgDef{
+			{Name: "SOURCE", Description: "the files to copy", Type: cobra.ArgTypeFile, Variadic: true},
+		},
+		Run: emptyRun,
+	}
+
+	buf := new(bytes.Buffer)
+	if err := GenYaml(c, buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+
+	checkStringContains(t, output, "usage: copy SOURCE...")
+	checkStringContains(t, output, "arguments:\n    - name: SOURCE\n      type: file\n      variadic: true\n      description: the files to copy")
+}
+
 func TestGenYamlNoTag(t *testing.T) {
 	rootCmd.DisableAutoGenTag = true
 	defer func() { rootCmd.DisableAutoGenTag = false }()
@@ -0,0 +1,174 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+func genElvishComp(buf io.StringWriter, name string, includeDesc bool) {
+	compCmd := ShellCompRequestCmd
+	if !includeDesc {
+		compCmd = ShellCompNoDescRequestCmd
+	}
+	WriteStringAndCheck(buf, fmt.Sprintf("# elvish completion for %-36s -*- shell-script -*-\n", name))
+	WriteStringAndCheck(buf, fmt.Sprintf(`
+use os
+use path
+use str
+
+set edit:completion:arg-completer['%[1]s'] = {|@words|
+    var shellCompDirectiveError = %[3]d
+    var shellCompDirectiveNoSpace = %[4]d
+    var shellCompDirectiveNoFileComp = %[5]d
+    var shellCompDirectiveFilterFileExt = %[6]d
+    var shellCompDirectiveFilterDirs = %[7]d
+    var shellCompDirectiveKeepOrder = %[8]d
+
+    # The last word is the one being completed; it is empty after a space
+    var lastArg = $words[-1]
+
+    # When completing a flag with an = (e.g., <program> -n=<TAB>)
+    # completions must be prefixed with the flag
+    var flagPrefix = ''
+    if (and (str:has-prefix $lastArg -) (str:contains $lastArg =)) {
+        set flagPrefix = $lastArg[..(+ (str:index $lastArg =) 1)]
+    }
+
+    var out = [(try { (external $words[0]) %[2]s $@words[1..] 2>$os:dev-null } catch { nop })]
+
+    # Some programs may output extra empty lines after the directive.
+    # Let's ignore them or else it will break completion.
+    # Ref: https://github.com/spf13/cobra/issues/1279
+    while (and (> (count $out) 0) (eq (str:trim-space $out[-1]) '')) {
+        set out = $out[..-1]
+    }
+
+    if (== (count $out) 0) {
+        # No completions, probably due to a failure; do file completion in case it helps
+        edit:complete-filename $lastArg
+        return
+    }
+
+    # The directive is the last line and has the format :<directive>
+    var d = (num $out[-1][1..])
+    var comps = $out[..-1]
+
+    # Decode the bits of the directive
+    var has = [&]
+    for bit [$shellCompDirectiveKeepOrder $shellCompDirectiveFilterDirs $shellCompDirectiveFilterFileExt $shellCompDirectiveNoFileComp $shellCompDirectiveNoSpace $shellCompDirectiveError] {
+        set has[$bit] = (>= $d $bit)
+        if $has[$bit] {
+            set d = (- $d $bit)
+        }
+    }
+
+    if $has[$shellCompDirectiveError] {
+        # Might as well do file completion, in case it helps
+        edit:complete-filename $lastArg
+        return
+    }
+
+    # Show any ActiveHelp messages as a notification
+    var activeHelp = [(each {|c| if (str:has-prefix $c '%[9]s') { put $c[(count '%[9]s')..] } } $comps)]
+    set comps = [(each {|c| if (not (str:has-prefix $c '%[9]s')) { put $c } } $comps)]
+    if (> (count $activeHelp) 0) {
+        edit:notify (str:join "\n" $activeHelp)
+    }
+
+    if $has[$shellCompDirectiveFilterFileExt] {
+        # The completions are the file extensions to keep
+        for f [(put $lastArg*[nomatch-ok])] {
+            if (path:is-dir $f) {
+                edit:complex-candidate $f &code-suffix=/
+            } else {
+                for ext $comps {
+                    if (str:has-suffix $f .$ext) {
+                        edit:complex-candidate $f &code-suffix=' '
+                        break
+                    }
+                }
+            }
+        }
+        return
+    }
+
+    if $has[$shellCompDirectiveFilterDirs] {
+        if (> (count $comps) 0) {
+            # Complete the directories found within the specified directory
+            var dir = $comps[0]
+            for f [(put $dir/$lastArg*[type:dir][nomatch-ok])] {
+                edit:complex-candidate $f[(+ (count $dir) 1)..] &code-suffix=/
+            }
+        } else {
+            for f [(put $lastArg*[type:dir][nomatch-ok])] {
+                edit:complex-candidate $f &code-suffix=/
+            }
+        }
+        return
+    }
+
+    if (not $has[$shellCompDirectiveKeepOrder]) {
+        set comps = [(order $comps)]
+    }
+
+    var suffix = ' '
+    if $has[$shellCompDirectiveNoSpace] {
+        set suffix = ''
+    }
+
+    for c $comps {
+        var parts = [(str:split &max=2 "\t" $c)]
+        var value = $flagPrefix$parts[0]
+        if (> (count $parts) 1) {
+            edit:complex-candidate $value &display=$value' ('$parts[1]')' &code-suffix=$suffix
+        } else {
+            edit:complex-candidate $value &code-suffix=$suffix
+        }
+    }
+
+    if (and (== (count $comps) 0) (not $has[$shellCompDirectiveNoFileComp])) {
+        # To be consistent with other shells, we only trigger file
+        # completion when there are no other completions
+        edit:complete-filename $lastArg
+    }
+}
+`, name, compCmd,
+		ShellCompDirectiveError, ShellCompDirectiveNoSpace, ShellCompDirectiveNoFileComp,
+		ShellCompDirectiveFilterFileExt, ShellCompDirectiveFilterDirs, ShellCompDirectiveKeepOrder,
+		activeHelpMarker))
+}
+
+// GenElvishCompletion generates elvish completion file and writes to the passed writer.
+func (c *Command) GenElvishCompletion(w io.Writer, includeDesc bool) error {
+	buf := new(bytes.Buffer)
+	genElvishComp(buf, c.Name(), includeDesc)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// GenElvishCompletionFile generates elvish completion file.
+func (c *Command) GenElvishCompletionFile(filename string, includeDesc bool) error {
+	outFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	return c.GenElvishCompletion(outFile, includeDesc)
+}
@@ -0,0 +1,86 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestCompleteNoDesCmdInElvishScript(t *testing.T) {
+	rootCmd := &Command{Use: "root", Args: NoArgs, Run: emptyRun}
+	child := &Command{
+		Use:               "child",
+		ValidArgsFunction: validArgsFunc,
+		Run:               emptyRun,
+	}
+	rootCmd.AddCommand(child)
+
+	buf := new(bytes.Buffer)
+	assertNoErr(t, rootCmd.GenElvishCompletion(buf, false))
+	output := buf.String()
+
+	check(t, output, ShellCompNoDescRequestCmd)
+}
+
+func TestCompleteCmdInElvishScript(t *testing.T) {
+	rootCmd := &Command{Use: "root", Args: NoArgs, Run: emptyRun}
+	child := &Command{
+		Use:               "child",
+		ValidArgsFunction: validArgsFunc,
+		Run:               emptyRun,
+	}
+	rootCmd.AddCommand(child)
+
+	buf := new(bytes.Buffer)
+	assertNoErr(t, rootCmd.GenElvishCompletion(buf, true))
+	output := buf.String()
+
+	check(t, output, ShellCompRequestCmd)
+	checkOmit(t, output, ShellCompNoDescRequestCmd)
+	check(t, output, activeHelpMarker)
+}
+
+func TestElvishCompletionDisableDescriptions(t *testing.T) {
+	rootCmd := &Command{Use: "root", Args: NoArgs, Run: emptyRun}
+	rootCmd.AddCommand(&Command{Use: "child", Run: emptyRun})
+	rootCmd.CompletionOptions.DisableDescriptions = true
+
+	output, err := executeCommand(rootCmd, compCmdName, "elvish")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	check(t, output, ShellCompNoDescRequestCmd)
+}
+
+func TestGenElvishCompletionFile(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "cobra-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	rootCmd := &Command{Use: "root", Args: NoArgs, Run: emptyRun}
+	child := &Command{
+		Use:               "child",
+		ValidArgsFunction: validArgsFunc,
+		Run:               emptyRun,
+	}
+	rootCmd.AddCommand(child)
+
+	assertNoErr(t, rootCmd.GenElvishCompletionFile(tmpFile.Name(), false))
+}
@@ -0,0 +1,223 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The errors below are returned when the command line is invalid.  Use errors.As
+// to retrieve them and customize the error messages.  Execute returns them
+// wrapped in a UsageError.
+
+// UnknownCommandError is returned when an argument does not match any subcommand.
+type UnknownCommandError struct {
+	// Arg is the unknown command.
+	Arg string
+	// CommandPath is the path of the command which has no subcommand named Arg.
+	CommandPath string
+	// Suggestions are the names of the subcommands close to Arg.
+	Suggestions []string
+}
+
+func (e *UnknownCommandError) Error() string {
+	return fmt.Sprintf("unknown command %q for %q%s", e.Arg, e.CommandPath, formatSuggestions(e.Suggestions))
+}
+
+// InvalidArgError is returned by OnlyValidArgs when an argument is not in ValidArgs.
+type InvalidArgError struct {
+	// Arg is the invalid argument.
+	Arg string
+	// CommandPath is the path of the command which received Arg.
+	CommandPath string
+	// Suggestions are the names of the subcommands close to the first argument.
+	Suggestions []string
+}
+
+func (e *InvalidArgError) Error() string {
+	return fmt.Sprintf("invalid argument %q for %q%s", e.Arg, e.CommandPath, formatSuggestions(e.Suggestions))
+}
+
+// DuplicateArgError is returned by NoDuplicateArgs when an argument is repeated.
+type DuplicateArgError struct {
+	// Arg is the repeated argument.
+	Arg string
+	// CommandPath is the path of the command which received Arg.
+	CommandPath string
+}
+
+func (e *DuplicateArgError) Error() string {
+	return fmt.Sprintf("duplicate argument %q for %q", e.Arg, e.CommandPath)
+}
+
+// InvalidArgCountError is returned by the PositionalArgs which check the number of
+// arguments: MinimumNArgs, MaximumNArgs, ExactArgs and RangeArgs.
+type InvalidArgCountError struct {
+	// Min and Max are the bounds of the number of arguments.
+	// A negative value means there is no bound.
+	Min, Max int
+	// Received is the number of arguments received.
+	Received int
+}
+
+func (e *InvalidArgCountError) Error() string {
+	switch {
+	case e.Max < 0:
+		return fmt.Sprintf("requires at least %d arg(s), only received %d", e.Min, e.Received)
+	case e.Min < 0:
+		return fmt.Sprintf("accepts at most %d arg(s), received %d", e.Max, e.Received)
+	case e.Min == e.Max:
+		return fmt.Sprintf("accepts %d arg(s), received %d", e.Max, e.Received)
+	default:
+		return fmt.Sprintf("accepts between %d and %d arg(s), received %d", e.Min, e.Max, e.Received)
+	}
+}
+
+// InvalidArgValueError is returned when an argument declared in ArgDefs has an invalid value.
+type InvalidArgValueError struct {
+	// Name is the name of the argument, as declared in ArgDefs.
+	Name string
+	// Value is the invalid value.
+	Value string
+	// Err is the reason why the value is invalid.
+	Err error
+}
+
+func (e *InvalidArgValueError) Error() string {
+	return fmt.Sprintf("invalid value %q for argument %s: %v", e.Value, e.Name, e.Err)
+}
+
+func (e *InvalidArgValueError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownFlagError is returned when the command line contains a flag which is not defined.
+type UnknownFlagError struct {
+	// Name is the name of the unknown flag, without dashes.  It is a single letter
+	// for an unknown shorthand.
+	Name string
+	// Shorthands is the part of the group of shorthands which starts with Name, without
+	// the dash, or an empty string if the unknown flag is not a shorthand.
+	Shorthands string
+	// CommandPath is the path of the command which received the flag.
+	CommandPath string
+	// Err is the error returned by pflag.
+	Err error
+}
+
+func (e *UnknownFlagError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UnknownFlagError) Unwrap() error {
+	return e.Err
+}
+
+// RequiredFlagsError is returned when flags marked as required are not set.
+type RequiredFlagsError struct {
+	// Flags are the names of the missing flags.
+	Flags []string
+}
+
+func (e *RequiredFlagsError) Error() string {
+	return fmt.Sprintf(`required flag(s) "%s" not set`, strings.Join(e.Flags, `", "`))
+}
+
+// FlagGroupKind is the kind of constraint of a flag group.
+type FlagGroupKind string
+
+const (
+	// FlagGroupRequiredTogether is the kind of the groups of MarkFlagsRequiredTogether.
+	FlagGroupRequiredTogether FlagGroupKind = "required together"
+	// FlagGroupOneRequired is the kind of the groups of MarkFlagsOneRequired.
+	FlagGroupOneRequired FlagGroupKind = "one required"
+	// FlagGroupMutuallyExclusive is the kind of the groups of MarkFlagsMutuallyExclusive.
+	FlagGroupMutuallyExclusive FlagGroupKind = "mutually exclusive"
+	// FlagGroupExactlyOne is the kind of the groups of MarkFlagsExactlyOne.
+	FlagGroupExactlyOne FlagGroupKind = "exactly one"
+)
+
+// FlagGroupError is returned when the flags of a group do not meet its constraint.
+type FlagGroupError struct {
+	// Kind is the constraint of the group.
+	Kind FlagGroupKind
+	// Flags are the names of the flags of the group.
+	Flags []string
+	// Set are the names of the flags of the group which are set, in alphabetical order.
+	Set []string
+	// Missing are the names of the flags of the group which are not set, in alphabetical order.
+	Missing []string
+}
+
+func (e *FlagGroupError) Error() string {
+	flagList := strings.Join(e.Flags, " ")
+	switch e.Kind {
+	case FlagGroupRequiredTogether:
+		return fmt.Sprintf("if any flags in the group [%v] are set they must all be set; missing %v", flagList, e.Missing)
+	case FlagGroupOneRequired:
+		return fmt.Sprintf("at least one of the flags in the group [%v] is required", flagList)
+	case FlagGroupMutuallyExclusive:
+		return fmt.Sprintf("if any flags in the group [%v] are set none of the others can be; %v were all set", flagList, e.Set)
+	case FlagGroupExactlyOne:
+		if len(e.Set) == 0 {
+			return fmt.Sprintf("exactly one of the flags in the group [%v] is required", flagList)
+		}
+		return fmt.Sprintf("exactly one of the flags in the group [%v] can be set; %v were all set", flagList, e.Set)
+	}
+	return fmt.Sprintf("invalid flags in the %s group [%v]", e.Kind, flagList)
+}
+
+// FlagRequirementError is returned when a flag is set but a flag it requires is not,
+// see MarkFlagRequires and MarkFlagRequiresIf.
+type FlagRequirementError struct {
+	// Flag is the name of the flag which has the requirement.
+	Flag string
+	// Value is the value of Flag which triggers the requirement, if OnValue is true.
+	Value string
+	// OnValue is true if the requirement only applies when Flag is set to Value.
+	OnValue bool
+
+	// Required is the name of the required flag.
+	Required string
+	// RequiredValue is the value Required must be set to, if HasRequiredValue is true.
+	RequiredValue string
+	// HasRequiredValue is true if Required must be set to RequiredValue.
+	HasRequiredValue bool
+}
+
+func (e *FlagRequirementError) Error() string {
+	trigger := fmt.Sprintf("flag %q", e.Flag)
+	if e.OnValue {
+		trigger += fmt.Sprintf(" set to %q", e.Value)
+	}
+	if e.HasRequiredValue {
+		return fmt.Sprintf("%s requires flag %q to be set to %q", trigger, e.Required, e.RequiredValue)
+	}
+	return fmt.Sprintf("%s requires flag %q to be set", trigger, e.Required)
+}
+
+// formatSuggestions returns the suggestions in the format appended to the
+// unknown command errors.
+func formatSuggestions(suggestions []string) string {
+	var sb strings.Builder
+	if len(suggestions) > 0 {
+		sb.WriteString("\n\nDid you mean this?\n")
+		for _, s := range suggestions {
+			_, _ = fmt.Fprintf(&sb, "\t%v\n", s)
+		}
+	}
+	return sb.String()
+}
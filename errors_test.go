@@ -0,0 +1,233 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUnknownCommandError(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(&Command{Use: "times", Run: emptyRun})
+
+	_, err := executeCommand(rootCmd, "time")
+	var unknownErr *UnknownCommandError
+	if !errors.As(err, &unknownErr) {
+		t.Fatalf("Expected an UnknownCommandError, got %#v", err)
+	}
+	expected := &UnknownCommandError{Arg: "time", CommandPath: "root", Suggestions: []string{"times"}}
+	if !reflect.DeepEqual(unknownErr, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, unknownErr)
+	}
+	if got := err.Error(); got != "unknown command \"time\" for \"root\"\n\nDid you mean this?\n\ttimes\n" {
+		t.Errorf("Unexpected message: %q", got)
+	}
+}
+
+func TestArgErrors(t *testing.T) {
+	c := &Command{Use: "c", ValidArgs: []string{"one", "two"}}
+
+	var invalidErr *InvalidArgError
+	if err := OnlyValidArgs(c, []string{"three"}); !errors.As(err, &invalidErr) || invalidErr.Arg != "three" {
+		t.Errorf("Expected an InvalidArgError for three, got %#v", err)
+	}
+
+	var duplicateErr *DuplicateArgError
+	if err := NoDuplicateArgs(c, []string{"one", "one"}); !errors.As(err, &duplicateErr) || duplicateErr.Arg != "one" {
+		t.Errorf("Expected a DuplicateArgError for one, got %#v", err)
+	}
+}
+
+func TestInvalidArgCountError(t *testing.T) {
+	c := &Command{Use: "c"}
+	testcases := []struct {
+		desc     string
+		validate PositionalArgs
+		args     []string
+		expected InvalidArgCountError
+	}{
+		{desc: "minimum", validate: MinimumNArgs(2), args: []string{"a"}, expected: InvalidArgCountError{Min: 2, Max: -1, Received: 1}},
+		{desc: "maximum", validate: MaximumNArgs(1), args: []string{"a", "b"}, expected: InvalidArgCountError{Min: -1, Max: 1, Received: 2}},
+		{desc: "exact", validate: ExactArgs(1), args: []string{}, expected: InvalidArgCountError{Min: 1, Max: 1, Received: 0}},
+		{desc: "range", validate: RangeArgs(1, 2), args: []string{"a", "b", "c"}, expected: InvalidArgCountError{Min: 1, Max: 2, Received: 3}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var countErr *InvalidArgCountError
+			if err := tc.validate(c, tc.args); !errors.As(err, &countErr) {
+				t.Fatalf("Expected an InvalidArgCountError, got %#v", err)
+			}
+			if *countErr != tc.expected {
+				t.Errorf("Expected %#v, got %#v", tc.expected, *countErr)
+			}
+		})
+	}
+}
+
+func TestInvalidArgValueError(t *testing.T) {
+	c := &Command{
+		Use:     "c",
+		ArgDefs: []ArgDef{{Name: "COUNT", Type: ArgTypeInt}},
+		Run:     emptyRun,
+	}
+
+	_, err := executeCommand(c, "many")
+	var valueErr *InvalidArgValueError
+	if !errors.As(err, &valueErr) {
+		t.Fatalf("Expected an InvalidArgValueError, got %#v", err)
+	}
+	if valueErr.Name != "COUNT" || valueErr.Value != "many" || valueErr.Err == nil {
+		t.Errorf("Unexpected error: %#v", valueErr)
+	}
+}
+
+func TestUnknownFlagError(t *testing.T) {
+	testcases := []struct {
+		args       []string
+		name       string
+		shorthands string
+		message    string
+	}{
+		{[]string{"--foo"}, "foo", "", "unknown flag: --foo"},
+		{[]string{"-vxv"}, "x", "xv", "unknown shorthand flag: 'x' in -xv"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			rootCmd := &Command{Use: "root", Run: emptyRun}
+			childCmd := &Command{Use: "child", Run: emptyRun}
+			childCmd.Flags().BoolP("verbose", "v", false, "")
+			rootCmd.AddCommand(childCmd)
+
+			_, err := executeCommand(rootCmd, append([]string{"child"}, tc.args...)...)
+			var unknownErr *UnknownFlagError
+			if !errors.As(err, &unknownErr) {
+				t.Fatalf("Expected an UnknownFlagError, got %#v", err)
+			}
+			if unknownErr.Name != tc.name || unknownErr.Shorthands != tc.shorthands || unknownErr.CommandPath != "root child" {
+				t.Errorf("Unexpected error: %#v", unknownErr)
+			}
+			if err.Error() != tc.message {
+				t.Errorf("Expected message %q, got %q", tc.message, err.Error())
+			}
+			if ExitCode(err) != ExitCodeUsage {
+				t.Errorf("Expected exit code %d, got %d", ExitCodeUsage, ExitCode(err))
+			}
+		})
+	}
+}
+
+func TestRequiredFlagsError(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().String("foo", "", "")
+	c.Flags().String("bar", "", "")
+	assertNoErr(t, c.MarkFlagRequired("foo"))
+	assertNoErr(t, c.MarkFlagRequired("bar"))
+
+	_, err := executeCommand(c)
+	var requiredErr *RequiredFlagsError
+	if !errors.As(err, &requiredErr) {
+		t.Fatalf("Expected a RequiredFlagsError, got %#v", err)
+	}
+	if expected := []string{"bar", "foo"}; !reflect.DeepEqual(requiredErr.Flags, expected) {
+		t.Errorf("Expected missing flags %v, got %v", expected, requiredErr.Flags)
+	}
+}
+
+func TestFlagGroupError(t *testing.T) {
+	newCommand := func() *Command {
+		c := &Command{Use: "c", Run: emptyRun}
+		for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+			c.Flags().Bool(name, false, "")
+		}
+		c.MarkFlagsRequiredTogether("a", "b")
+		c.MarkFlagsMutuallyExclusive("c", "d")
+		c.MarkFlagsExactlyOne("e", "f")
+		return c
+	}
+
+	testcases := []struct {
+		desc     string
+		args     []string
+		oneOf    bool
+		expected FlagGroupError
+	}{
+		{
+			desc:     "required together",
+			args:     []string{"--a", "--e"},
+			expected: FlagGroupError{Kind: FlagGroupRequiredTogether, Flags: []string{"a", "b"}, Set: []string{"a"}, Missing: []string{"b"}},
+		},
+		{
+			desc:     "mutually exclusive",
+			args:     []string{"--c", "--d", "--e"},
+			expected: FlagGroupError{Kind: FlagGroupMutuallyExclusive, Flags: []string{"c", "d"}, Set: []string{"c", "d"}},
+		},
+		{
+			desc:     "exactly one",
+			args:     []string{"--e", "--f"},
+			expected: FlagGroupError{Kind: FlagGroupExactlyOne, Flags: []string{"e", "f"}, Set: []string{"e", "f"}},
+		},
+		{
+			desc:     "one required",
+			args:     []string{"--e"},
+			oneOf:    true,
+			expected: FlagGroupError{Kind: FlagGroupOneRequired, Flags: []string{"g", "h"}, Missing: []string{"g", "h"}},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := newCommand()
+			if tc.oneOf {
+				c.MarkFlagsOneRequired("g", "h")
+			}
+			_, err := executeCommand(c, tc.args...)
+			var groupErr *FlagGroupError
+			if !errors.As(err, &groupErr) {
+				t.Fatalf("Expected a FlagGroupError, got %#v", err)
+			}
+			if !reflect.DeepEqual(*groupErr, tc.expected) {
+				t.Errorf("Expected %#v, got %#v", tc.expected, *groupErr)
+			}
+		})
+	}
+}
+
+func TestFlagRequirementError(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().String("format", "", "")
+	c.Flags().String("output", "", "")
+	c.MarkFlagRequiresIf("format", "json", "output=file")
+
+	_, err := executeCommand(c, "--format", "json")
+	var requirementErr *FlagRequirementError
+	if !errors.As(err, &requirementErr) {
+		t.Fatalf("Expected a FlagRequirementError, got %#v", err)
+	}
+	expected := FlagRequirementError{
+		Flag:             "format",
+		Value:            "json",
+		OnValue:          true,
+		Required:         "output",
+		RequiredValue:    "file",
+		HasRequiredValue: true,
+	}
+	if *requirementErr != expected {
+		t.Errorf("Expected %#v, got %#v", expected, *requirementErr)
+	}
+	if got := err.Error(); got != `flag "format" set to "json" requires flag "output" to be set to "file"` {
+		t.Errorf("Unexpected message: %q", got)
+	}
+}
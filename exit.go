@@ -0,0 +1,174 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	flag "github.com/spf13/pflag"
+)
+
+const (
+	// ExitCodeOK is the exit code of a successful execution.
+	ExitCodeOK = 0
+	// ExitCodeError is the exit code of an execution which failed at runtime.
+	ExitCodeError = 1
+	// ExitCodeUsage is the exit code of an execution which failed because the
+	// command line was invalid: unknown command or flag, bad arguments, missing
+	// required flags...
+	ExitCodeUsage = 2
+)
+
+// exitFunc is a variable for testing purposes.
+var exitFunc = os.Exit
+
+// ExitCoder is the interface of the errors which determine the exit code of the program.
+// Return one from RunE to choose the code used by ExecuteAndExit.
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+// ExitError is an error with an exit code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// Error returns the message of the wrapped error.
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return ""
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of the error.
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// UsageError is returned by Execute when Cobra finds the command line invalid: unknown
+// command or flag, invalid flag value, arguments rejected by the validators of Cobra such
+// as ExactArgs, missing required flags or flag groups.  Its exit code is ExitCodeUsage.
+//
+// The errors returned by a custom FlagErrorFunc or Args validator are returned unchanged:
+// wrap them in a UsageError to exit with ExitCodeUsage.
+type UsageError struct {
+	Err error
+}
+
+// Error returns the message of the wrapped error.
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns ExitCodeUsage.
+func (e *UsageError) ExitCode() int {
+	return ExitCodeUsage
+}
+
+// usageError wraps err in a UsageError, unless it already has an exit code.
+func usageError(err error) error {
+	var coder ExitCoder
+	if err == nil || errors.Is(err, flag.ErrHelp) || errors.As(err, &coder) {
+		return err
+	}
+	return &UsageError{Err: err}
+}
+
+// flagError returns the error to report when the flags of c are invalid: the error
+// of the FlagErrorFunc set for c or a parent, unchanged, or else a UsageError.
+func (c *Command) flagError(err error) error {
+	for p := c; p != nil; p = p.Parent() {
+		if p.flagErrorFunc != nil {
+			return p.flagErrorFunc(c, err)
+		}
+	}
+	return usageError(err)
+}
+
+// argsError returns the error to report when the arguments are rejected: the errors
+// of the validators of Cobra are usage errors, while the errors of the custom validators
+// and the invalid ArgDefs declarations are returned unchanged.
+func argsError(err error) error {
+	switch err.(type) {
+	case *UnknownCommandError, *InvalidArgError, *DuplicateArgError, *InvalidArgCountError, *InvalidArgValueError:
+		return usageError(err)
+	}
+	return err
+}
+
+// ExitCode returns the exit code matching err: ExitCodeOK if err is nil,
+// the code of the first ExitCoder found in the chain of err, or ExitCodeError.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeOK
+	}
+	var coder ExitCoder
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+	return ExitCodeError
+}
+
+// CheckErrExitCode is like CheckErr, but exits with the exit code matching err,
+// see ExitCode.  If err is nil, it does nothing.
+func CheckErrExitCode(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		exitFunc(ExitCode(err))
+	}
+}
+
+// ExecuteAndExit executes the command like Execute(), then exits the program
+// with the exit code matching the returned error.  See ExitCode.
+func (c *Command) ExecuteAndExit() {
+	cmd, err := c.ExecuteC()
+	if cmd == nil {
+		cmd = c
+	}
+	cmd.ExitFunc()(ExitCode(err))
+}
+
+// SetExitFunc sets the function called to exit the program, by ExecuteAndExit
+// and ExecuteWithSignals.  It defaults to os.Exit; override it in tests.
+func (c *Command) SetExitFunc(f func(code int)) {
+	c.exitFunc = f
+}
+
+// ExitFunc returns either the function set by SetExitFunc for this command
+// or a parent, or os.Exit.
+func (c *Command) ExitFunc() func(code int) {
+	if c.exitFunc != nil {
+		return c.exitFunc
+	}
+	if c.HasParent() {
+		return c.parent.ExitFunc()
+	}
+	return exitFunc
+}
@@ -0,0 +1,205 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	testcases := []struct {
+		desc string
+		err  error
+		code int
+	}{
+		{desc: "nil", err: nil, code: ExitCodeOK},
+		{desc: "plain error", err: errors.New("failed"), code: ExitCodeError},
+		{desc: "exit error", err: &ExitError{Code: 3, Err: errors.New("failed")}, code: 3},
+		{desc: "wrapped exit error", err: fmt.Errorf("context: %w", &ExitError{Code: 4}), code: 4},
+		{desc: "usage error", err: &UsageError{Err: errors.New("bad")}, code: ExitCodeUsage},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := ExitCode(tc.err); got != tc.code {
+				t.Errorf("Expected exit code %d, got %d", tc.code, got)
+			}
+		})
+	}
+}
+
+func TestExecuteExitCodes(t *testing.T) {
+	newRoot := func() *Command {
+		rootCmd := &Command{Use: "root", Args: NoArgs, Run: emptyRun}
+		childCmd := &Command{
+			Use:  "child",
+			Args: ExactArgs(1),
+			RunE: func(cmd *Command, args []string) error {
+				if args[0] == "fail" {
+					return errors.New("runtime failure")
+				}
+				if args[0] == "exit" {
+					return &ExitError{Code: 5, Err: errors.New("custom failure")}
+				}
+				return nil
+			},
+		}
+		childCmd.Flags().String("req", "", "required")
+		assertNoErr(t, childCmd.MarkFlagRequired("req"))
+		childCmd.Flags().Bool("a", false, "a")
+		childCmd.Flags().Bool("b", false, "b")
+		childCmd.MarkFlagsMutuallyExclusive("a", "b")
+		rootCmd.AddCommand(childCmd)
+		return rootCmd
+	}
+
+	testcases := []struct {
+		desc  string
+		args  []string
+		code  int
+		usage bool
+	}{
+		{desc: "success", args: []string{"child", "--req", "x", "ok"}, code: ExitCodeOK},
+		{desc: "unknown command", args: []string{"unknown"}, code: ExitCodeUsage, usage: true},
+		{desc: "unknown flag", args: []string{"child", "--unknown"}, code: ExitCodeUsage, usage: true},
+		{desc: "bad args", args: []string{"child", "--req", "x"}, code: ExitCodeUsage, usage: true},
+		{desc: "missing required flag", args: []string{"child", "ok"}, code: ExitCodeUsage, usage: true},
+		{desc: "flag group", args: []string{"child", "--req", "x", "--a", "--b", "ok"}, code: ExitCodeUsage, usage: true},
+		{desc: "runtime failure", args: []string{"child", "--req", "x", "fail"}, code: ExitCodeError},
+		{desc: "custom exit code", args: []string{"child", "--req", "x", "exit"}, code: 5},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := executeCommand(newRoot(), tc.args...)
+			if got := ExitCode(err); got != tc.code {
+				t.Errorf("Expected exit code %d, got %d (error: %v)", tc.code, got, err)
+			}
+			var usageErr *UsageError
+			if errors.As(err, &usageErr) != tc.usage {
+				t.Errorf("Expected UsageError to be %v, got %v", tc.usage, err)
+			}
+		})
+	}
+}
+
+func TestExecuteUserErrorsUnchanged(t *testing.T) {
+	customErr := errors.New("custom")
+	testcases := []struct {
+		desc  string
+		cmd   *Command
+		args  []string
+		check func(err error) bool
+	}{
+		{
+			desc: "flag error func",
+			cmd: func() *Command {
+				c := &Command{Use: "c", Run: emptyRun}
+				c.SetFlagErrorFunc(func(*Command, error) error { return customErr })
+				return c
+			}(),
+			args:  []string{"--unknown"},
+			check: func(err error) bool { return err == customErr },
+		}, {
+			desc:  "args validator",
+			cmd:   &Command{Use: "c", Args: func(*Command, []string) error { return customErr }, Run: emptyRun},
+			check: func(err error) bool { return err == customErr },
+		}, {
+			desc: "invalid ArgDefs declaration",
+			cmd:  &Command{Use: "c", ArgDefs: []ArgDef{{Name: "a", Optional: true}, {Name: "b"}}, Run: emptyRun},
+			args: []string{"x", "y"},
+			check: func(err error) bool {
+				return err != nil && ExitCode(err) == ExitCodeError
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := executeCommand(tc.cmd, tc.args...)
+			if !tc.check(err) {
+				t.Errorf("Unexpected error: %#v", err)
+			}
+		})
+	}
+}
+
+func TestExecuteExitCodeKeepsMessage(t *testing.T) {
+	rootCmd := &Command{Use: "root", Args: NoArgs, Run: emptyRun}
+
+	output, err := executeCommand(rootCmd, "extra")
+	expected := `unknown command "extra" for "root"`
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected error %q, got %v", expected, err)
+	}
+	checkStringContains(t, output, "Error: "+expected)
+}
+
+func TestFlagErrorFuncExitCode(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.SetFlagErrorFunc(func(_ *Command, err error) error {
+		return &ExitError{Code: 64, Err: err}
+	})
+
+	_, err := executeCommand(rootCmd, "--unknown")
+	if got := ExitCode(err); got != 64 {
+		t.Errorf("Expected exit code 64, got %d", got)
+	}
+}
+
+func TestExecuteAndExit(t *testing.T) {
+	var code int
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{
+		Use:  "child",
+		RunE: func(*Command, []string) error { return &ExitError{Code: 3, Err: errors.New("failed")} },
+	}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.SetExitFunc(func(c int) { code = c })
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+
+	rootCmd.SetArgs([]string{"child"})
+	rootCmd.ExecuteAndExit()
+	if code != 3 {
+		t.Errorf("Expected exit code 3, got %d", code)
+	}
+
+	rootCmd.SetArgs([]string{})
+	rootCmd.ExecuteAndExit()
+	if code != ExitCodeOK {
+		t.Errorf("Expected exit code %d, got %d", ExitCodeOK, code)
+	}
+}
+
+func TestCheckErrExitCode(t *testing.T) {
+	code := -1
+	exitFunc = func(c int) { code = c }
+	defer func() { exitFunc = os.Exit }()
+
+	CheckErrExitCode(nil)
+	if code != -1 {
+		t.Errorf("Expected no exit, got %d", code)
+	}
+	CheckErrExitCode(&ExitError{Code: 7, Err: errors.New("failed")})
+	if code != 7 {
+		t.Errorf("Expected exit code 7, got %d", code)
+	}
+	CheckErrExitCode(errors.New("failed"))
+	if code != ExitCodeError {
+		t.Errorf("Expected exit code %d, got %d", ExitCodeError, code)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	"go.yaml.in/yaml/v3"
+)
+
+// flagSourceAnnotation holds where the value of a flag came from when it was not
+// set on the command-line.
+const flagSourceAnnotation = "cobra_annotation_flag_source"
+
+// The possible sources of the value of a flag, as returned by Command.FlagSource.
+// Sources are listed from the highest to the lowest precedence.
+const (
+	FlagSourceCommandLine = "command-line"
+	FlagSourceEnv         = "env"
+	FlagSourceConfig      = "config"
+	FlagSourceDefault     = "default"
+)
+
+// ConfigLoader provides the configuration values of a command tree.
+// The returned map is keyed by flag name or by subcommand name. The value of a
+// subcommand name is a nested map of the same form, holding the values for that
+// subcommand. For example, the following YAML configures the persistent
+// flag "verbose" of the root command and the flag "port" of the "serve" subcommand:
+//
+//	verbose: true
+//	serve:
+//	  port: 8080
+//
+// The loader is called with the command being executed, after its flags have been
+// parsed, so it can use a flag such as --config to find the configuration.
+type ConfigLoader func(cmd *Command) (map[string]interface{}, error)
+
+// ConfigFile returns a ConfigLoader which reads the YAML or JSON file at path.
+// A file which does not exist is treated as an empty configuration.
+func ConfigFile(path string) ConfigLoader {
+	return func(cmd *Command) (map[string]interface{}, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		config := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+		}
+		return config, nil
+	}
+}
+
+// SetConfigLoader sets the loader used to obtain flag values from a configuration.
+// Flags which are set neither on the command-line nor from the environment take
+// their value from the configuration, before falling back to their default.
+// The loader applies to c and its subcommands; the loader of the closest command
+// is used, and the names of the subcommands in the configuration start below it.
+func (c *Command) SetConfigLoader(l ConfigLoader) {
+	c.configLoader = l
+}
+
+// FlagSource returns where the value of the named flag came from: one of
+// FlagSourceCommandLine, FlagSourceEnv, FlagSourceConfig or FlagSourceDefault.
+// It returns an empty string if the flag does not exist.
+func (c *Command) FlagSource(name string) string {
+	f := c.Flag(name)
+	if f == nil {
+		return ""
+	}
+	return flagSource(f)
+}
+
+func flagSource(f *flag.Flag) string {
+	if !f.Changed {
+		return FlagSourceDefault
+	}
+	if source := f.Annotations[flagSourceAnnotation]; len(source) > 0 {
+		return source[0]
+	}
+	return FlagSourceCommandLine
+}
+
+// applyFlagConfig sets all flags which have not been set yet to the value
+// found in the configuration returned by the closest ConfigLoader.
+// Values configured for a subcommand take precedence over the ones configured
+// for its parents.
+func (c *Command) applyFlagConfig() error {
+	if c.DisableFlagParsing {
+		return nil
+	}
+	owner := c
+	for owner != nil && owner.configLoader == nil {
+		owner = owner.Parent()
+	}
+	if owner == nil {
+		return nil
+	}
+	config, err := owner.configLoader(c)
+	if err != nil {
+		return err
+	}
+
+	// Collect the values from the command of the loader down to this command
+	// so the most specific value wins.
+	path := []*Command{}
+	for p := c; p != owner; p = p.Parent() {
+		path = append([]*Command{p}, path...)
+	}
+	flags := c.Flags()
+	values := map[string]interface{}{}
+	level := config
+	collect := func(level map[string]interface{}) {
+		for key, val := range level {
+			if flags.Lookup(key) != nil {
+				values[key] = val
+			}
+		}
+	}
+	collect(level)
+	for _, p := range path {
+		next, ok := level[p.Name()].(map[string]interface{})
+		if !ok {
+			break
+		}
+		level = next
+		collect(level)
+	}
+
+	flags.VisitAll(func(f *flag.Flag) {
+		val, found := values[f.Name]
+		if err != nil || !found || f.Changed || len(f.Annotations[FlagSetByCobraAnnotation]) > 0 {
+			return
+		}
+		for _, s := range configValueStrings(val) {
+			if setErr := flags.Set(f.Name, s); setErr != nil {
+				err = fmt.Errorf("invalid value %q for flag --%s from config: %v", s, f.Name, setErr)
+				return
+			}
+		}
+		setFlagAnnotation(f, flagSourceAnnotation, []string{FlagSourceConfig})
+	})
+	return err
+}
+
+// configValueStrings converts a configuration value into the strings to pass
+// to the Set method of a flag. Lists set the flag once per element, which
+// appends to slice and array flags; maps are passed as key=value pairs.
+func configValueStrings(val interface{}) []string {
+	switch v := val.(type) {
+	case []interface{}:
+		strs := make([]string, 0, len(v))
+		for _, e := range v {
+			strs = append(strs, fmt.Sprint(e))
+		}
+		return strs
+	case map[string]interface{}:
+		pairs := make([]string, 0, len(v))
+		for k, e := range v {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", k, e))
+		}
+		sort.Strings(pairs)
+		return []string{strings.Join(pairs, ",")}
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
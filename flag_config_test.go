@@ -0,0 +1,195 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	dir, err := os.MkdirTemp("", "cobra-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFlagsFromConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `
+verbose: true
+port: 1
+serve:
+  port: 8080
+  tags: [a, b]
+`)
+	var verbose bool
+	var port int
+	var tags []string
+	rootCmd := &Command{Use: "root"}
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "")
+	rootCmd.PersistentFlags().IntVar(&port, "port", 0, "")
+	serveCmd := &Command{Use: "serve", Run: emptyRun}
+	serveCmd.Flags().StringSliceVar(&tags, "tags", []string{"x"}, "")
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.SetConfigLoader(ConfigFile(path))
+
+	_, err := executeCommand(rootCmd, "serve")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !verbose {
+		t.Error("Expected verbose to be set from the config")
+	}
+	if port != 8080 {
+		t.Errorf("Expected the subcommand value to take precedence, got %d", port)
+	}
+	if !reflect.DeepEqual(tags, []string{"a", "b"}) {
+		t.Errorf("Expected tags [a b], got %v", tags)
+	}
+	if source := serveCmd.FlagSource("port"); source != FlagSourceConfig {
+		t.Errorf("Expected source %q, got %q", FlagSourceConfig, source)
+	}
+}
+
+func TestFlagSourcePrecedence(t *testing.T) {
+	var a, b, c, d string
+	rootCmd := &Command{Use: "root", EnvPrefix: "root", Run: emptyRun}
+	rootCmd.Flags().StringVar(&a, "a", "default", "")
+	rootCmd.Flags().StringVar(&b, "b", "default", "")
+	rootCmd.Flags().StringVar(&c, "c", "default", "")
+	rootCmd.Flags().StringVar(&d, "d", "default", "")
+	assertNoErr(t, rootCmd.MarkFlagRequired("c"))
+	rootCmd.SetConfigLoader(func(*Command) (map[string]interface{}, error) {
+		return map[string]interface{}{"a": "config", "b": "config", "c": "config"}, nil
+	})
+
+	os.Setenv("ROOT_A", "env")
+	os.Setenv("ROOT_B", "env")
+	defer os.Unsetenv("ROOT_A")
+	defer os.Unsetenv("ROOT_B")
+
+	_, err := executeCommand(rootCmd, "--a", "flag")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	expected := map[string][2]string{
+		"a": {"flag", FlagSourceCommandLine},
+		"b": {"env", FlagSourceEnv},
+		"c": {"config", FlagSourceConfig},
+		"d": {"default", FlagSourceDefault},
+	}
+	values := map[string]string{"a": a, "b": b, "c": c, "d": d}
+	for name, exp := range expected {
+		if values[name] != exp[0] {
+			t.Errorf("Expected flag %s to be %q, got %q", name, exp[0], values[name])
+		}
+		if source := rootCmd.FlagSource(name); source != exp[1] {
+			t.Errorf("Expected source of flag %s to be %q, got %q", name, exp[1], source)
+		}
+	}
+}
+
+func TestConfigFileInvalidValue(t *testing.T) {
+	path := writeConfigFile(t, "num: abc\n")
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().Int("num", 0, "")
+	c.SetConfigLoader(ConfigFile(path))
+
+	_, err := executeCommand(c)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	checkStringContains(t, err.Error(), `invalid value "abc" for flag --num from config`)
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	path := writeConfigFile(t, "num: [1\n")
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().Int("num", 0, "")
+	c.SetConfigLoader(ConfigFile(path))
+
+	// The help does not need the configuration
+	if _, err := executeCommand(c, "--help"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	c = &Command{Use: "c", Run: emptyRun}
+	c.Flags().Int("num", 0, "")
+	c.SetConfigLoader(ConfigFile(path))
+	c.SetFlagErrorFunc(func(c *Command, err error) error {
+		return fmt.Errorf("flag error: %v", err)
+	})
+	_, err := executeCommand(c)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	checkStringContains(t, err.Error(), "flag error: invalid config file")
+}
+
+func TestConfigLoaderOnSubcommand(t *testing.T) {
+	path := writeConfigFile(t, "port: 1\nstart:\n  port: 8080\n")
+	var port int
+	rootCmd := &Command{Use: "root"}
+	serveCmd := &Command{Use: "serve"}
+	startCmd := &Command{Use: "start", Run: emptyRun}
+	startCmd.Flags().IntVar(&port, "port", 0, "")
+	serveCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.SetConfigLoader(ConfigFile(path))
+
+	if _, err := executeCommand(rootCmd, "serve", "start"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if port != 8080 {
+		t.Errorf("Expected the value configured below the command of the loader, got %d", port)
+	}
+}
+
+func TestConfigFileMissing(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().Int("num", 0, "")
+	c.SetConfigLoader(ConfigFile(filepath.Join(os.TempDir(), "cobra-does-not-exist.yaml")))
+
+	if _, err := executeCommand(c); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestDebugFlagsShowsSource(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+	c.Flags().String("foo", "", "")
+	c.SetConfigLoader(func(*Command) (map[string]interface{}, error) {
+		return map[string]interface{}{"foo": "bar"}, nil
+	})
+	if _, err := executeCommand(c); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	c.SetOut(buf)
+	c.DebugFlags()
+	checkStringContains(t, buf.String(), "(config)")
+}
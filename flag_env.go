@@ -0,0 +1,147 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"fmt"
+	"os"
+
+	flag "github.com/spf13/pflag"
+)
+
+const (
+	// flagEnvAnnotation holds the environment variable explicitly bound to a flag.
+	// An empty value means the flag has opted out of environment binding.
+	flagEnvAnnotation = "cobra_annotation_flag_env"
+)
+
+// BindFlagEnv binds the named flag to the environment variable envVar,
+// regardless of the EnvPrefix of the command.
+// A flag bound to an environment variable which is not set on the command-line
+// takes its value from that variable when the command is executed.
+func (c *Command) BindFlagEnv(name string, envVar string) error {
+	f := c.Flag(name)
+	if f == nil {
+		return fmt.Errorf("BindFlagEnv: flag '%s' does not exist", name)
+	}
+	if envVar == "" {
+		return fmt.Errorf("BindFlagEnv: no environment variable specified for flag '%s'", name)
+	}
+	setFlagAnnotation(f, flagEnvAnnotation, []string{envVar})
+	return nil
+}
+
+// MarkFlagNoEnv prevents the named flag from being populated from the environment,
+// even if EnvPrefix is set on the command or one of its parents.
+func (c *Command) MarkFlagNoEnv(name string) error {
+	f := c.Flag(name)
+	if f == nil {
+		return fmt.Errorf("MarkFlagNoEnv: flag '%s' does not exist", name)
+	}
+	setFlagAnnotation(f, flagEnvAnnotation, []string{""})
+	return nil
+}
+
+func setFlagAnnotation(f *flag.Flag, key string, values []string) {
+	if f.Annotations == nil {
+		f.Annotations = map[string][]string{}
+	}
+	f.Annotations[key] = values
+}
+
+// envPrefix returns the EnvPrefix of the command or of its closest parent
+// which defines one.
+func (c *Command) envPrefix() string {
+	for p := c; p != nil; p = p.Parent() {
+		if p.EnvPrefix != "" {
+			return p.EnvPrefix
+		}
+	}
+	return ""
+}
+
+// flagEnvVar returns the name of the environment variable bound to the flag
+// for this command, or an empty string if the flag is not bound.
+// The name has the format <PREFIX>_<FLAG> in upper case, with all
+// non-ASCII-alphanumeric characters replaced by `_`.
+func (c *Command) flagEnvVar(f *flag.Flag) string {
+	if len(f.Annotations[FlagSetByCobraAnnotation]) > 0 {
+		return ""
+	}
+	if envVar, found := f.Annotations[flagEnvAnnotation]; found && len(envVar) > 0 {
+		// An empty value means the flag opted out of environment binding
+		return envVar[0]
+	}
+	if prefix := c.envPrefix(); prefix != "" {
+		return configEnvVar(prefix, f.Name)
+	}
+	return ""
+}
+
+// applyFlagEnv sets all flags which are bound to an environment variable and
+// have not been set on the command-line to the value of that variable.
+// Flags set this way are marked as changed, so that required flags
+// and flag groups are satisfied by the environment.
+func (c *Command) applyFlagEnv() error {
+	if c.DisableFlagParsing {
+		return nil
+	}
+
+	var err error
+	flags := c.Flags()
+	flags.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		if f.Changed {
+			// The flag was set on the command-line
+			delete(f.Annotations, flagSourceAnnotation)
+			return
+		}
+		envVar := c.flagEnvVar(f)
+		if envVar == "" {
+			return
+		}
+		val, ok := os.LookupEnv(envVar)
+		if !ok {
+			return
+		}
+		if setErr := flags.Set(f.Name, val); setErr != nil {
+			err = fmt.Errorf("invalid value %q for flag --%s from environment variable %s: %v", val, f.Name, envVar, setErr)
+			return
+		}
+		setFlagAnnotation(f, flagSourceAnnotation, []string{FlagSourceEnv})
+	})
+	return err
+}
+
+// FlagsWithEnvUsage returns a copy of flags in which the usage of each flag bound to an
+// environment variable for this command ends with the name of the variable, such as
+// "(env $APP_REGION)".  The flags are copied because they are shared with the parents and
+// the subcommands, which may bind them to other variables.
+// The help and the generated documentation show the flags of a command this way.
+func (c *Command) FlagsWithEnvUsage(flags *flag.FlagSet) *flag.FlagSet {
+	withEnv := flag.NewFlagSet(c.DisplayName(), flag.ContinueOnError)
+	withEnv.SortFlags = flags.SortFlags
+	flags.VisitAll(func(f *flag.Flag) {
+		if envVar := c.flagEnvVar(f); envVar != "" {
+			withUsage := *f
+			withUsage.Usage = fmt.Sprintf("%s (env $%s)", f.Usage, envVar)
+			f = &withUsage
+		}
+		withEnv.AddFlag(f)
+	})
+	return withEnv
+}
@@ -0,0 +1,149 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFlagFromEnvPrefix(t *testing.T) {
+	var foo string
+	var count int
+	rootCmd := &Command{Use: "root", EnvPrefix: "my-app"}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	rootCmd.PersistentFlags().StringVar(&foo, "foo-bar", "", "")
+	childCmd.Flags().IntVar(&count, "count", 0, "")
+	rootCmd.AddCommand(childCmd)
+
+	os.Setenv("MY_APP_FOO_BAR", "env")
+	os.Setenv("MY_APP_COUNT", "3")
+	defer os.Unsetenv("MY_APP_FOO_BAR")
+	defer os.Unsetenv("MY_APP_COUNT")
+
+	_, err := executeCommand(rootCmd, "child", "--count", "5")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if foo != "env" {
+		t.Errorf("Expected foo-bar to be set from the environment, got %q", foo)
+	}
+	if count != 5 {
+		t.Errorf("Expected the command-line to take precedence over the environment, got %d", count)
+	}
+}
+
+func TestRequiredFlagFromEnv(t *testing.T) {
+	c := &Command{Use: "c", EnvPrefix: "c", Run: emptyRun}
+	c.Flags().String("foo", "", "")
+	assertNoErr(t, c.MarkFlagRequired("foo"))
+
+	os.Setenv("C_FOO", "bar")
+	defer os.Unsetenv("C_FOO")
+
+	if _, err := executeCommand(c); err != nil {
+		t.Errorf("Expected required flag to be satisfied by the environment, got: %v", err)
+	}
+}
+
+func TestBindFlagEnvAndMarkFlagNoEnv(t *testing.T) {
+	var foo, bar string
+	c := &Command{Use: "c", EnvPrefix: "c", Run: emptyRun}
+	c.Flags().StringVar(&foo, "foo", "", "")
+	c.Flags().StringVar(&bar, "bar", "", "")
+	assertNoErr(t, c.BindFlagEnv("foo", "CUSTOM_FOO"))
+	assertNoErr(t, c.MarkFlagNoEnv("bar"))
+
+	os.Setenv("CUSTOM_FOO", "custom")
+	os.Setenv("C_FOO", "prefixed")
+	os.Setenv("C_BAR", "bar")
+	defer os.Unsetenv("CUSTOM_FOO")
+	defer os.Unsetenv("C_FOO")
+	defer os.Unsetenv("C_BAR")
+
+	if _, err := executeCommand(c); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if foo != "custom" {
+		t.Errorf("Expected foo to be set from CUSTOM_FOO, got %q", foo)
+	}
+	if bar != "" {
+		t.Errorf("Expected bar not to be set from the environment, got %q", bar)
+	}
+
+	if err := c.BindFlagEnv("unknown", "X"); err == nil {
+		t.Error("Expected error binding an unknown flag")
+	}
+}
+
+func TestFlagFromEnvInvalidValue(t *testing.T) {
+	c := &Command{Use: "c", EnvPrefix: "c", Run: emptyRun}
+	c.Flags().Int("num", 0, "")
+
+	os.Setenv("C_NUM", "abc")
+	defer os.Unsetenv("C_NUM")
+
+	_, err := executeCommand(c)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	checkStringContains(t, err.Error(), "from environment variable C_NUM")
+}
+
+func TestFlagEnvInHelp(t *testing.T) {
+	c := &Command{Use: "c", EnvPrefix: "c", Run: emptyRun}
+	c.Flags().String("foo", "", "the foo")
+	c.Flags().String("bar", "", "the bar")
+	assertNoErr(t, c.MarkFlagNoEnv("bar"))
+
+	output, err := executeCommand(c, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "the foo (env $C_FOO)")
+	checkStringOmits(t, output, "C_BAR")
+	checkStringOmits(t, output, "C_HELP")
+
+	// Calling help again must not repeat the environment variable
+	output, _ = executeCommand(c, "--help")
+	if strings.Count(output, "$C_FOO") != 1 {
+		t.Errorf("Expected the environment variable to be shown once, got:\n%s", output)
+	}
+}
+
+func TestFlagEnvInHelpOfEachCommand(t *testing.T) {
+	rootCmd := &Command{Use: "root", EnvPrefix: "APP", Run: emptyRun}
+	rootCmd.PersistentFlags().String("region", "", "the region")
+	childCmd := &Command{Use: "child", EnvPrefix: "SUB", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+
+	output, err := executeCommand(rootCmd, "child", "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "the region (env $SUB_REGION)")
+
+	// The flag is shared with the child, which binds it to another variable
+	output, err = executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "the region (env $APP_REGION)")
+	checkStringOmits(t, output, "SUB_REGION")
+	if usage := rootCmd.PersistentFlags().Lookup("region").Usage; usage != "the region" {
+		t.Errorf("Expected the usage of the flag to be unchanged, got %q", usage)
+	}
+}
@@ -15,6 +15,7 @@
 package cobra
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -26,6 +27,12 @@ const (
 	requiredAsGroupAnnotation   = "cobra_annotation_required_if_others_set"
 	oneRequiredAnnotation       = "cobra_annotation_one_required"
 	mutuallyExclusiveAnnotation = "cobra_annotation_mutually_exclusive"
+	exactlyOneAnnotation        = "cobra_annotation_exactly_one"
+	requiresAnnotation          = "cobra_annotation_requires"
+
+	// requiresAnyValue is the condition of a requires annotation which applies
+	// whatever the value of the flag.  Other conditions are of the form "=<value>".
+	requiresAnyValue = "*"
 )
 
 // MarkFlagsRequiredTogether marks the given flags with annotations so that Cobra errors
@@ -76,8 +83,81 @@ func (c *Command) MarkFlagsMutuallyExclusive(flagNames ...string) {
 	}
 }
 
-// ValidateFlagGroups validates the mutuallyExclusive/oneRequired/requiredAsGroup logic and returns the
-// first error encountered.
+// MarkFlagsExactlyOne marks the given flags with annotations so that Cobra errors
+// if the command is not invoked with exactly one flag from the given set of flags.
+func (c *Command) MarkFlagsExactlyOne(flagNames ...string) {
+	c.mergePersistentFlags()
+	for _, v := range flagNames {
+		f := c.Flags().Lookup(v)
+		if f == nil {
+			panic(fmt.Sprintf("Failed to find flag %q and mark it as being in an exactly-one flag group", v))
+		}
+		if err := c.Flags().SetAnnotation(v, exactlyOneAnnotation, append(f.Annotations[exactlyOneAnnotation], strings.Join(flagNames, " "))); err != nil {
+			// Only errs if the flag isn't found.
+			panic(err)
+		}
+	}
+}
+
+// MarkFlagRequires marks the given flag with an annotation so that Cobra errors
+// if the command is invoked with this flag but without the required flags.
+// A required flag can be specified as "name=value" to also require its value; the
+// requirement is met if the flag has this value, even by default.  For a slice flag,
+// the value must be one of the values of the flag.
+func (c *Command) MarkFlagRequires(flagName string, requiredFlags ...string) {
+	c.markFlagRequires(flagName, requiresAnyValue, requiredFlags)
+}
+
+// MarkFlagRequiresIf marks the given flag with an annotation so that Cobra errors
+// if the command is invoked with this flag set to value but without the required flags.
+// For a slice flag, value must be one of the values of the flag.  A required flag can be
+// specified as "name=value" to also require its value, as for MarkFlagRequires.
+func (c *Command) MarkFlagRequiresIf(flagName, value string, requiredFlags ...string) {
+	c.markFlagRequires(flagName, "="+value, requiredFlags)
+}
+
+func (c *Command) markFlagRequires(flagName, condition string, requiredFlags []string) {
+	c.mergePersistentFlags()
+	for _, v := range append([]string{flagName}, requiredFlags...) {
+		name := strings.SplitN(v, "=", 2)[0]
+		if c.Flags().Lookup(name) == nil {
+			panic(fmt.Sprintf("Failed to find flag %q and mark it as being in a requires constraint", name))
+		}
+	}
+	f := c.Flags().Lookup(flagName)
+	// Each time this is called is a single new entry; this allows a flag to have multiple constraints.
+	constraint, err := json.Marshal(flagConstraint{Condition: condition, Requires: requiredFlags})
+	if err != nil {
+		panic(err)
+	}
+	if err := c.Flags().SetAnnotation(flagName, requiresAnnotation, append(f.Annotations[requiresAnnotation], string(constraint))); err != nil {
+		panic(err)
+	}
+}
+
+// flagConstraint is a requires constraint, stored as JSON in the requires annotation
+// so that the values can contain any character.
+type flagConstraint struct {
+	// Condition is requiresAnyValue, or "=<value>" if the constraint applies to a value of the flag.
+	Condition string `json:"condition"`
+	// Requires are the required flags, as "name" or "name=value".
+	Requires []string `json:"requires"`
+}
+
+// flagConstraints returns the requires constraints of f.
+func flagConstraints(f *flag.Flag) []flagConstraint {
+	var constraints []flagConstraint
+	for _, annotation := range f.Annotations[requiresAnnotation] {
+		var constraint flagConstraint
+		if err := json.Unmarshal([]byte(annotation), &constraint); err == nil {
+			constraints = append(constraints, constraint)
+		}
+	}
+	return constraints
+}
+
+// ValidateFlagGroups validates the mutuallyExclusive/oneRequired/exactlyOne/requiredAsGroup logic
+// and the requires constraints and returns the first error encountered.
 func (c *Command) ValidateFlagGroups() error {
 	if c.DisableFlagParsing {
 		return nil
@@ -90,10 +170,12 @@ func (c *Command) ValidateFlagGroups() error {
 	groupStatus := map[string]map[string]bool{}
 	oneRequiredGroupStatus := map[string]map[string]bool{}
 	mutuallyExclusiveGroupStatus := map[string]map[string]bool{}
+	exactlyOneGroupStatus := map[string]map[string]bool{}
 	flags.VisitAll(func(pflag *flag.Flag) {
 		processFlagForGroupAnnotation(flags, pflag, requiredAsGroupAnnotation, groupStatus)
 		processFlagForGroupAnnotation(flags, pflag, oneRequiredAnnotation, oneRequiredGroupStatus)
 		processFlagForGroupAnnotation(flags, pflag, mutuallyExclusiveAnnotation, mutuallyExclusiveGroupStatus)
+		processFlagForGroupAnnotation(flags, pflag, exactlyOneAnnotation, exactlyOneGroupStatus)
 	})
 
 	if err := validateRequiredFlagGroups(groupStatus); err != nil {
@@ -105,6 +187,12 @@ func (c *Command) ValidateFlagGroups() error {
 	if err := validateExclusiveFlagGroups(mutuallyExclusiveGroupStatus); err != nil {
 		return err
 	}
+	if err := validateExactlyOneFlagGroups(exactlyOneGroupStatus); err != nil {
+		return err
+	}
+	if err := validateFlagRequirements(flags); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -158,7 +246,7 @@ func validateRequiredFlagGroups(data map[string]map[string]bool) error {
 
 		// Sort values, so they can be tested/scripted against consistently.
 		sort.Strings(unset)
-		return fmt.Errorf("if any flags in the group [%v] are set they must all be set; missing %v", flagList, unset)
+		return &FlagGroupError{Kind: FlagGroupRequiredTogether, Flags: strings.Split(flagList, " "), Set: setFlags(flagnameAndStatus), Missing: unset}
 	}
 
 	return nil
@@ -178,9 +266,7 @@ func validateOneRequiredFlagGroups(data map[string]map[string]bool) error {
 			continue
 		}
 
-		// Sort values, so they can be tested/scripted against consistently.
-		sort.Strings(set)
-		return fmt.Errorf("at least one of the flags in the group [%v] is required", flagList)
+		return &FlagGroupError{Kind: FlagGroupOneRequired, Flags: strings.Split(flagList, " "), Missing: unsetFlags(flagnameAndStatus)}
 	}
 	return nil
 }
@@ -201,11 +287,146 @@ func validateExclusiveFlagGroups(data map[string]map[string]bool) error {
 
 		// Sort values, so they can be tested/scripted against consistently.
 		sort.Strings(set)
-		return fmt.Errorf("if any flags in the group [%v] are set none of the others can be; %v were all set", flagList, set)
+		return &FlagGroupError{Kind: FlagGroupMutuallyExclusive, Flags: strings.Split(flagList, " "), Set: set, Missing: unsetFlags(flagnameAndStatus)}
+	}
+	return nil
+}
+
+func validateExactlyOneFlagGroups(data map[string]map[string]bool) error {
+	keys := sortedKeys(data)
+	for _, flagList := range keys {
+		flagnameAndStatus := data[flagList]
+		var set []string
+		for flagname, isSet := range flagnameAndStatus {
+			if isSet {
+				set = append(set, flagname)
+			}
+		}
+		if len(set) == 1 {
+			continue
+		}
+		// Sort values, so they can be tested/scripted against consistently.
+		sort.Strings(set)
+		return &FlagGroupError{Kind: FlagGroupExactlyOne, Flags: strings.Split(flagList, " "), Set: set, Missing: unsetFlags(flagnameAndStatus)}
 	}
 	return nil
 }
 
+// flagRequirement is a requirement of a requires constraint: a flag which must be set,
+// with a specific value if hasValue is true.
+type flagRequirement struct {
+	name     string
+	value    string
+	hasValue bool
+}
+
+func parseFlagRequirement(spec string) flagRequirement {
+	parts := strings.SplitN(spec, "=", 2)
+	if len(parts) == 1 {
+		return flagRequirement{name: parts[0]}
+	}
+	return flagRequirement{name: parts[0], value: parts[1], hasValue: true}
+}
+
+// satisfiedBy returns true if f, which must be the flag named by the requirement, meets it.
+// A required value can be the default value of the flag.
+func (r flagRequirement) satisfiedBy(f *flag.Flag) bool {
+	if !r.hasValue {
+		return f.Changed
+	}
+	return flagHasValue(f, r.value)
+}
+
+// flagHasValue returns true if the value of f is value, or if value is one of the values of a slice flag.
+func flagHasValue(f *flag.Flag, value string) bool {
+	if sv, ok := f.Value.(flag.SliceValue); ok {
+		for _, v := range sv.GetSlice() {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+	return f.Value.String() == value
+}
+
+// activeFlagRequirements calls fn for each requires constraint of the flags whose condition
+// is met, with the flag and the requirements of the constraint.
+func activeFlagRequirements(flags *flag.FlagSet, fn func(f *flag.Flag, condition string, requirements []flagRequirement)) {
+	flags.VisitAll(func(f *flag.Flag) {
+		if !f.Changed {
+			return
+		}
+		for _, constraint := range flagConstraints(f) {
+			condition := constraint.Condition
+			if condition != requiresAnyValue && !flagHasValue(f, strings.TrimPrefix(condition, "=")) {
+				continue
+			}
+
+			requirements := make([]flagRequirement, 0, len(constraint.Requires))
+			for _, spec := range constraint.Requires {
+				requirements = append(requirements, parseFlagRequirement(spec))
+			}
+			fn(f, condition, requirements)
+		}
+	})
+}
+
+func validateFlagRequirements(flags *flag.FlagSet) error {
+	var err error
+	activeFlagRequirements(flags, func(f *flag.Flag, condition string, requirements []flagRequirement) {
+		if err != nil {
+			return
+		}
+		for _, r := range requirements {
+			required := flags.Lookup(r.name)
+			if required == nil || r.satisfiedBy(required) {
+				// Only consider the requirements on flags defined for the command.
+				continue
+			}
+
+			value := f.Value.String()
+			if condition != requiresAnyValue {
+				value = strings.TrimPrefix(condition, "=")
+			}
+			err = &FlagRequirementError{
+				Flag:             f.Name,
+				Value:            value,
+				OnValue:          condition != requiresAnyValue,
+				Required:         r.name,
+				RequiredValue:    r.value,
+				HasRequiredValue: r.hasValue,
+			}
+			return
+		}
+	})
+	return err
+}
+
+// setFlags returns the names of the flags of a group which are set, in alphabetical order.
+func setFlags(flagnameAndStatus map[string]bool) []string {
+	var set []string
+	for flagname, isSet := range flagnameAndStatus {
+		if isSet {
+			set = append(set, flagname)
+		}
+	}
+	sort.Strings(set)
+	return set
+}
+
+// unsetFlags returns the names of the flags of a group which are not set, in alphabetical order.
+func unsetFlags(flagnameAndStatus map[string]bool) []string {
+	var unset []string
+	for flagname, isSet := range flagnameAndStatus {
+		if !isSet {
+			unset = append(unset, flagname)
+		}
+	}
+	sort.Strings(unset)
+	return unset
+}
+
 func sortedKeys(m map[string]map[string]bool) []string {
 	keys := make([]string, len(m))
 	i := 0
@@ -221,20 +442,28 @@ func sortedKeys(m map[string]map[string]bool) []string {
 // - when a flag in a group is present, other flags in the group will be marked required
 // - when none of the flags in a one-required group are present, all flags in the group will be marked required
 // - when a flag in a mutually exclusive group is present, other flags in the group will be marked as hidden
-// This allows the standard completion logic to behave appropriately for flag groups
-func (c *Command) enforceFlagGroupsForCompletion() {
+// - when none of the flags in an exactly-one group are present, all flags in the group will be marked required
+// - when a flag in an exactly-one group is present, other flags in the group will be marked as hidden
+// - when the condition of a requires constraint is met, the required flags will be marked required
+// This allows the standard completion logic to behave appropriately for flag groups.
+// It returns a function restoring the flags, for the commands which are completed
+// several times, such as by the completion server.
+func (c *Command) enforceFlagGroupsForCompletion() (restore func()) {
 	if c.DisableFlagParsing {
-		return
+		return func() {}
 	}
 
 	flags := c.Flags()
+	restore = saveFlagsForCompletion(flags)
 	groupStatus := map[string]map[string]bool{}
 	oneRequiredGroupStatus := map[string]map[string]bool{}
 	mutuallyExclusiveGroupStatus := map[string]map[string]bool{}
+	exactlyOneGroupStatus := map[string]map[string]bool{}
 	c.Flags().VisitAll(func(pflag *flag.Flag) {
 		processFlagForGroupAnnotation(flags, pflag, requiredAsGroupAnnotation, groupStatus)
 		processFlagForGroupAnnotation(flags, pflag, oneRequiredAnnotation, oneRequiredGroupStatus)
 		processFlagForGroupAnnotation(flags, pflag, mutuallyExclusiveAnnotation, mutuallyExclusiveGroupStatus)
+		processFlagForGroupAnnotation(flags, pflag, exactlyOneAnnotation, exactlyOneGroupStatus)
 	})
 
 	// If a flag that is part of a group is present, we make all the other flags
@@ -287,4 +516,60 @@ func (c *Command) enforceFlagGroupsForCompletion() {
 			}
 		}
 	}
+
+	// An exactly-one group behaves like a one-required group when none of its flags
+	// are present, and like a mutually exclusive group once one of them is present
+	for flagList, flagnameAndStatus := range exactlyOneGroupStatus {
+		setFlag := ""
+		for flagName, isSet := range flagnameAndStatus {
+			if isSet {
+				setFlag = flagName
+				break
+			}
+		}
+
+		for _, fName := range strings.Split(flagList, " ") {
+			if setFlag == "" {
+				_ = c.MarkFlagRequired(fName)
+			} else if fName != setFlag {
+				c.Flags().Lookup(fName).Hidden = true
+			}
+		}
+	}
+
+	// If the condition of a requires constraint is met, we make the required flags
+	// required so that the shell completion suggests them automatically
+	activeFlagRequirements(flags, func(f *flag.Flag, condition string, requirements []flagRequirement) {
+		for _, r := range requirements {
+			if required := flags.Lookup(r.name); required != nil && !r.satisfiedBy(required) {
+				_ = c.MarkFlagRequired(r.name)
+			}
+		}
+	})
+	return restore
+}
+
+// saveFlagsForCompletion returns a function restoring the Hidden field and the
+// required annotation of the flags, which enforceFlagGroupsForCompletion changes.
+func saveFlagsForCompletion(flags *flag.FlagSet) func() {
+	type flagState struct {
+		hidden   bool
+		required []string
+		found    bool
+	}
+	saved := map[*flag.Flag]flagState{}
+	flags.VisitAll(func(f *flag.Flag) {
+		required, found := f.Annotations[BashCompOneRequiredFlag]
+		saved[f] = flagState{hidden: f.Hidden, required: required, found: found}
+	})
+	return func() {
+		for f, state := range saved {
+			f.Hidden = state.hidden
+			if state.found {
+				f.Annotations[BashCompOneRequiredFlag] = state.required
+			} else {
+				delete(f.Annotations, BashCompOneRequiredFlag)
+			}
+		}
+	}
 }
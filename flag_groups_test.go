@@ -50,6 +50,8 @@ func TestValidateFlagGroups(t *testing.T) {
 		subCmdFlagGroupsRequired    []string
 		subCmdFlagGroupsOneRequired []string
 		subCmdFlagGroupsExclusive   []string
+		flagGroupsExactlyOne        []string
+		flagRequires                []string
 		args                        []string
 		expectErr                   string
 	}{
@@ -154,6 +156,56 @@ func TestValidateFlagGroups(t *testing.T) {
 			desc:                      "Subcmds can use exclusive groups using inherited flags and pass",
 			subCmdFlagGroupsExclusive: []string{"e subonly"},
 			args:                      []string{"subcmd", "--e=foo"},
+		}, {
+			desc:                 "Exactly-one flag group with no flag set",
+			flagGroupsExactlyOne: []string{"a b c"},
+			args:                 []string{"--d=foo"},
+			expectErr:            "exactly one of the flags in the group [a b c] is required",
+		}, {
+			desc:                 "Exactly-one flag group with multiple flags set",
+			flagGroupsExactlyOne: []string{"a b c"},
+			args:                 []string{"--a=foo", "--c=foo"},
+			expectErr:            "exactly one of the flags in the group [a b c] can be set; [a c] were all set",
+		}, {
+			desc:                 "Exactly-one flag group satisfied",
+			flagGroupsExactlyOne: []string{"a b c"},
+			args:                 []string{"--b=foo"},
+		}, {
+			desc:         "Requires constraint not satisfied",
+			flagRequires: []string{"a b c"},
+			args:         []string{"--a=foo", "--b=foo"},
+			expectErr:    `flag "a" requires flag "c" to be set`,
+		}, {
+			desc:         "Requires constraint satisfied",
+			flagRequires: []string{"a b c"},
+			args:         []string{"--a=foo", "--b=foo", "--c=foo"},
+		}, {
+			desc:         "Requires constraint not applied if the flag is not set",
+			flagRequires: []string{"a b"},
+			args:         []string{"--c=foo"},
+		}, {
+			desc:         "Requires constraint with a required value not satisfied",
+			flagRequires: []string{"a b=json"},
+			args:         []string{"--a=foo", "--b=yaml"},
+			expectErr:    `flag "a" requires flag "b" to be set to "json"`,
+		}, {
+			desc:         "Requires constraint with a required value satisfied",
+			flagRequires: []string{"a b=json"},
+			args:         []string{"--a=foo", "--b=json"},
+		}, {
+			desc:         "Conditional requires constraint not satisfied",
+			flagRequires: []string{"a=tls b"},
+			args:         []string{"--a=tls"},
+			expectErr:    `flag "a" set to "tls" requires flag "b" to be set`,
+		}, {
+			desc:         "Conditional requires constraint not applied for other values",
+			flagRequires: []string{"a=tls b"},
+			args:         []string{"--a=plain"},
+		}, {
+			desc:         "Requires constraint using inherited flags",
+			flagRequires: []string{"e f"},
+			args:         []string{"subcmd", "--e=foo"},
+			expectErr:    `flag "e" requires flag "f" to be set`,
 		}, {
 			desc:                     "Flag groups not applied if not found on invoked command",
 			subCmdFlagGroupsRequired: []string{"e subonly"},
@@ -182,6 +234,17 @@ func TestValidateFlagGroups(t *testing.T) {
 			for _, flagGroup := range tc.subCmdFlagGroupsExclusive {
 				sub.MarkFlagsMutuallyExclusive(strings.Split(flagGroup, " ")...)
 			}
+			for _, flagGroup := range tc.flagGroupsExactlyOne {
+				c.MarkFlagsExactlyOne(strings.Split(flagGroup, " ")...)
+			}
+			for _, constraint := range tc.flagRequires {
+				flags := strings.Split(constraint, " ")
+				if trigger := strings.SplitN(flags[0], "=", 2); len(trigger) == 2 {
+					c.MarkFlagRequiresIf(trigger[0], trigger[1], flags[1:]...)
+				} else {
+					c.MarkFlagRequires(flags[0], flags[1:]...)
+				}
+			}
 			c.SetArgs(tc.args)
 			err := c.Execute()
 			switch {
@@ -193,3 +256,53 @@ func TestValidateFlagGroups(t *testing.T) {
 		})
 	}
 }
+
+func TestFlagRequiresValues(t *testing.T) {
+	testcases := []struct {
+		desc      string
+		mark      func(c *Command)
+		args      []string
+		expectErr string
+	}{
+		{
+			desc:      "Values with spaces",
+			mark:      func(c *Command) { c.MarkFlagRequiresIf("mode", "dry run", "format=plain text") },
+			args:      []string{"--mode", "dry run", "--format", "plain"},
+			expectErr: `flag "mode" set to "dry run" requires flag "format" to be set to "plain text"`,
+		}, {
+			desc: "Values with spaces satisfied",
+			mark: func(c *Command) { c.MarkFlagRequiresIf("mode", "dry run", "format=plain text") },
+			args: []string{"--mode", "dry run", "--format", "plain text"},
+		}, {
+			desc:      "Slice flag containing the value",
+			mark:      func(c *Command) { c.MarkFlagRequiresIf("tags", "prod", "mode") },
+			args:      []string{"--tags", "dev,prod"},
+			expectErr: `flag "tags" set to "prod" requires flag "mode" to be set`,
+		}, {
+			desc: "Slice flag without the value",
+			mark: func(c *Command) { c.MarkFlagRequiresIf("tags", "prod", "mode") },
+			args: []string{"--tags", "dev"},
+		}, {
+			desc: "Required value set by default",
+			mark: func(c *Command) { c.MarkFlagRequires("mode", "format=json") },
+			args: []string{"--mode", "fast"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := &Command{Use: "c", Run: emptyRun}
+			c.Flags().String("mode", "", "")
+			c.Flags().String("format", "json", "")
+			c.Flags().StringSlice("tags", nil, "")
+			tc.mark(c)
+
+			_, err := executeCommand(c, tc.args...)
+			switch {
+			case err == nil && len(tc.expectErr) > 0:
+				t.Errorf("Expected error %q but got nil", tc.expectErr)
+			case err != nil && err.Error() != tc.expectErr:
+				t.Errorf("Expected error %q but got %q", tc.expectErr, err)
+			}
+		})
+	}
+}
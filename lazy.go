@@ -0,0 +1,75 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import "fmt"
+
+// AddLazyCommand adds a subcommand to c which is only built when it is needed, to keep
+// the startup of programs with many subcommands fast.
+//
+// Until then, the subcommand is represented by stub, of which only the Use, Aliases,
+// Short, GroupID and Hidden fields are used: they are enough to list the subcommand in
+// the help of c and to complete its name.  The subcommand is built by calling build when
+// Find or Traverse reach it, e.g. to execute it, complete its arguments or show its help,
+// and when the documentation is generated.  The command returned by build, which must
+// have the same name as the stub, then replaces the stub in the subcommands of c.
+func (c *Command) AddLazyCommand(stub *Command, build func() *Command) {
+	stub.lazyBuild = build
+	c.AddCommand(stub)
+}
+
+// BuildLazyCommands builds the subcommands of c added with AddLazyCommand, which is needed
+// before walking the whole tree of commands.  Building a subcommand may add lazy subcommands
+// to it, so call BuildLazyCommands on each subcommand in turn.
+func (c *Command) BuildLazyCommands() {
+	for _, sub := range c.commands {
+		c.buildLazyCommand(sub)
+	}
+}
+
+// buildLazyCommand builds the subcommand sub of c if it is a stub, and returns the built command.
+func (c *Command) buildLazyCommand(sub *Command) *Command {
+	if sub.lazyBuild == nil {
+		return sub
+	}
+	built := sub.lazyBuild()
+	if built == nil || built.Name() != sub.Name() {
+		panic(fmt.Sprintf("lazy command %q was built as a command with another name", sub.CommandPath()))
+	}
+	// Keep finding the command by the aliases of the stub
+	for _, alias := range sub.Aliases {
+		if !built.HasAlias(alias) {
+			built.Aliases = append(built.Aliases, alias)
+		}
+	}
+	if built.GroupID == "" {
+		built.GroupID = sub.GroupID
+	}
+	built.commandCalledAs = sub.commandCalledAs
+
+	for i, cmd := range c.commands {
+		if cmd == sub {
+			c.commands[i] = built
+		}
+	}
+	built.parent = c
+	if c.globNormFunc != nil {
+		built.SetGlobalNormalizationFunc(c.globNormFunc)
+	}
+	if usageLen := len(built.Use); usageLen > c.commandsMaxUseLen {
+		c.commandsMaxUseLen = usageLen
+	}
+	return built
+}
@@ -0,0 +1,176 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLazyCommandNotBuilt(t *testing.T) {
+	var built []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(&Command{Use: "status", Short: "Show the status", Run: emptyRun})
+	rootCmd.AddLazyCommand(&Command{Use: "deploy", Short: "Deploy a release"}, func() *Command {
+		built = append(built, "deploy")
+		return &Command{Use: "deploy", Run: emptyRun}
+	})
+	rootCmd.AddLazyCommand(&Command{Use: "secret", Short: "Hidden command", Hidden: true}, func() *Command {
+		built = append(built, "secret")
+		return &Command{Use: "secret", Run: emptyRun}
+	})
+
+	output, err := executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "  deploy      Deploy a release\n")
+	checkStringOmits(t, output, "secret")
+	checkStringOmits(t, output, "Additional help topics")
+
+	for _, args := range [][]string{
+		{"status"},
+		{ShellCompNoDescRequestCmd, ""},
+		{ShellCompNoDescRequestCmd, "help", ""},
+	} {
+		output, err = executeCommand(rootCmd, args...)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+	}
+	checkStringContains(t, output, "deploy\n")
+	if len(built) != 0 {
+		t.Errorf("Expected no command to be built, got %v", built)
+	}
+}
+
+func TestLazyCommandExecution(t *testing.T) {
+	var built []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddLazyCommand(&Command{Use: "deploy", Aliases: []string{"ship"}, Short: "Deploy a release"}, func() *Command {
+		built = append(built, "deploy")
+		deployCmd := &Command{
+			Use: "deploy RELEASE",
+			Run: func(cmd *Command, args []string) {
+				env, _ := cmd.Flags().GetString("env")
+				cmd.Printf("deploying %v to %s\n", args, env)
+			},
+		}
+		deployCmd.Flags().String("env", "dev", "the environment")
+		return deployCmd
+	})
+
+	output, err := executeCommand(rootCmd, "ship", "v1", "--env", "prod")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "deploying [v1] to prod")
+
+	var deployCmd *Command
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == "deploy" {
+			deployCmd = c
+		}
+	}
+	if deployCmd.Use != "deploy RELEASE" || deployCmd.Parent() != rootCmd || deployCmd.CalledAs() != "ship" {
+		t.Errorf("Expected the built command to replace the stub, got %q called as %q", deployCmd.Use, deployCmd.CalledAs())
+	}
+	if len(built) != 1 {
+		t.Errorf("Expected the command to be built once, got %v", built)
+	}
+}
+
+func TestLazyCommandCompletionAndHelp(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddLazyCommand(&Command{Use: "deploy", Short: "Deploy a release"}, func() *Command {
+		deployCmd := &Command{Use: "deploy RELEASE", Short: "Deploy a release", Run: emptyRun}
+		deployCmd.Flags().String("env", "dev", "the environment")
+		return deployCmd
+	})
+
+	output, err := executeCommand(rootCmd, ShellCompNoDescRequestCmd, "deploy", "--e")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "--env\n")
+
+	output, err = executeCommand(rootCmd, "help", "deploy")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "root deploy RELEASE [flags]")
+	checkStringContains(t, output, "the environment")
+}
+
+func TestLazyCommandTraverse(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun, TraverseChildren: true}
+	rootCmd.AddLazyCommand(&Command{Use: "deploy", Short: "Deploy a release"}, func() *Command {
+		return &Command{
+			Use:  "deploy",
+			Args: ExactArgs(1),
+			Run:  func(cmd *Command, args []string) { cmd.Printf("deploying %v\n", args) },
+		}
+	})
+
+	output, err := executeCommand(rootCmd, "deploy", "v2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "deploying [v2]")
+}
+
+func TestBuildLazyCommands(t *testing.T) {
+	var built []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddLazyCommand(&Command{Use: "deploy", Short: "Deploy a release"}, func() *Command {
+		built = append(built, "deploy")
+		deployCmd := &Command{Use: "deploy", Run: emptyRun}
+		deployCmd.Flags().String("env", "dev", "")
+		return deployCmd
+	})
+	rootCmd.AddLazyCommand(&Command{Use: "secret", Hidden: true}, func() *Command {
+		built = append(built, "secret")
+		return &Command{Use: "secret", Run: emptyRun}
+	})
+
+	rootCmd.BuildLazyCommands()
+	if len(built) != 2 {
+		t.Errorf("Expected all the commands to be built, got %v", built)
+	}
+	for _, c := range rootCmd.Commands() {
+		if c.lazyBuild != nil {
+			t.Errorf("Expected %s to be built", c.Name())
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	assertNoErr(t, rootCmd.GenBashCompletion(buf))
+	checkStringContains(t, buf.String(), "flags+=(\"--env=\")")
+}
+
+func TestLazyCommandBuiltWithAnotherName(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddLazyCommand(&Command{Use: "deploy"}, func() *Command {
+		return &Command{Use: "release", Run: emptyRun}
+	})
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "another name") {
+			t.Errorf("Expected a panic, got %v", r)
+		}
+	}()
+	_, _ = executeCommand(rootCmd, "deploy")
+}
@@ -0,0 +1,48 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+// Middleware wraps the execution of a command.  cmd is the command being executed
+// and args are its arguments, without the flags.  Call next, at most once, to continue
+// the execution: a middleware can run code before and after it, change the error
+// it returns, or not call it at all to stop the execution.
+type Middleware func(cmd *Command, args []string, next func() error) error
+
+// UseMiddleware adds middlewares around the execution of the command and its children.
+//
+// The middlewares of the parents are run first, from the root to the executed command,
+// and those of a command are run in the order they were added.  They wrap the validation
+// of the arguments and flags, and all the *Run functions of the executed command,
+// including the persistent ones of its parents.
+func (c *Command) UseMiddleware(middlewares ...Middleware) {
+	c.middlewares = append(c.middlewares, middlewares...)
+}
+
+// runMiddlewares runs run wrapped in the middlewares of the command and its parents.
+func (c *Command) runMiddlewares(args []string, run func() error) error {
+	var chain []Middleware
+	for p := c; p != nil; p = p.Parent() {
+		chain = append(append([]Middleware{}, p.middlewares...), chain...)
+	}
+
+	next := run
+	for i := len(chain) - 1; i >= 0; i-- {
+		middleware, inner := chain[i], next
+		next = func() error {
+			return middleware(c, args, inner)
+		}
+	}
+	return next()
+}
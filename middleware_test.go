@@ -0,0 +1,150 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(cmd *Command, args []string, next func() error) error {
+			calls = append(calls, fmt.Sprintf("%s before %s %v", name, cmd.Name(), args))
+			err := next()
+			calls = append(calls, name+" after")
+			return err
+		}
+	}
+	hook := func(name string) func(*Command, []string) {
+		return func(*Command, []string) { calls = append(calls, name) }
+	}
+
+	rootCmd := &Command{
+		Use:               "root",
+		PersistentPreRun:  hook("root persistent pre-run"),
+		PersistentPostRun: hook("root persistent post-run"),
+	}
+	childCmd := &Command{
+		Use:     "child",
+		PreRun:  hook("child pre-run"),
+		Run:     hook("child run"),
+		PostRun: hook("child post-run"),
+	}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.UseMiddleware(record("root 1"), record("root 2"))
+	childCmd.UseMiddleware(record("child"))
+
+	if _, err := executeCommand(rootCmd, "child", "one", "two"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"root 1 before child [one two]",
+		"root 2 before child [one two]",
+		"child before child [one two]",
+		"root persistent pre-run",
+		"child pre-run",
+		"child run",
+		"child post-run",
+		"root persistent post-run",
+		"child after",
+		"root 2 after",
+		"root 1 after",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected calls:\n%s\nGot:\n%s", strings.Join(expected, "\n"), strings.Join(calls, "\n"))
+	}
+}
+
+func TestMiddlewareNotInheritedBySiblings(t *testing.T) {
+	var called bool
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	siblingCmd := &Command{Use: "sibling", Run: emptyRun}
+	rootCmd.AddCommand(childCmd, siblingCmd)
+	childCmd.UseMiddleware(func(cmd *Command, args []string, next func() error) error {
+		called = true
+		return next()
+	})
+
+	if _, err := executeCommand(rootCmd, "sibling"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if called {
+		t.Error("Expected the middleware of a command not to wrap its siblings")
+	}
+}
+
+func TestMiddlewareStopsExecution(t *testing.T) {
+	var ran bool
+	denied := errors.New("denied")
+	rootCmd := &Command{
+		Use: "root",
+		Run: func(*Command, []string) { ran = true },
+	}
+	rootCmd.UseMiddleware(func(cmd *Command, args []string, next func() error) error {
+		return denied
+	})
+
+	_, err := executeCommand(rootCmd)
+	if err != denied {
+		t.Errorf("Expected error %v, got %v", denied, err)
+	}
+	if ran {
+		t.Error("Expected the command not to run")
+	}
+}
+
+func TestMiddlewareWrapsErrors(t *testing.T) {
+	rootCmd := &Command{
+		Use: "root",
+		RunE: func(*Command, []string) error {
+			panic("boom")
+		},
+	}
+	rootCmd.UseMiddleware(func(cmd *Command, args []string, next func() error) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("recovered: %v", r)
+			}
+		}()
+		return next()
+	})
+
+	_, err := executeCommand(rootCmd)
+	if err == nil || err.Error() != "recovered: boom" {
+		t.Errorf("Expected the recovered error, got %v", err)
+	}
+}
+
+func TestMiddlewareSeesUsageErrors(t *testing.T) {
+	var seen error
+	rootCmd := &Command{Use: "root", Args: ExactArgs(1), Run: emptyRun}
+	rootCmd.UseMiddleware(func(cmd *Command, args []string, next func() error) error {
+		seen = next()
+		return seen
+	})
+
+	_, err := executeCommand(rootCmd)
+	var countErr *InvalidArgCountError
+	if !errors.As(seen, &countErr) || seen != err {
+		t.Errorf("Expected the middleware to see the argument error, got %v", seen)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func genNushellComp(buf io.StringWriter, name string, includeDesc bool) {
+	// Variables should not contain a '-' or ':' character
+	nameForVar := name
+	nameForVar = strings.ReplaceAll(nameForVar, "-", "_")
+	nameForVar = strings.ReplaceAll(nameForVar, ":", "_")
+
+	compCmd := ShellCompRequestCmd
+	if !includeDesc {
+		compCmd = ShellCompNoDescRequestCmd
+	}
+	WriteStringAndCheck(buf, fmt.Sprintf("# nushell completion for %-36s -*- shell-script -*-\n", name))
+	WriteStringAndCheck(buf, fmt.Sprintf(`
+# An external completer for %[2]s which obtains the completion choices
+# from the program itself.
+let __%[1]s_completer = {|spans: list<string>|
+    let ShellCompDirectiveError = %[4]d
+    let ShellCompDirectiveNoSpace = %[5]d
+    let ShellCompDirectiveNoFileComp = %[6]d
+    let ShellCompDirectiveFilterFileExt = %[7]d
+    let ShellCompDirectiveFilterDirs = %[8]d
+    let ShellCompDirectiveKeepOrder = %[9]d
+
+    # The last span is the word being completed; it is empty after a space
+    let args = $spans | skip 1
+    let lastArg = $args | last
+
+    # When completing a flag with an = (e.g., <program> -n=<TAB>)
+    # completions must be prefixed with the flag
+    let flagPrefix = if ($lastArg | str starts-with "-") and ($lastArg | str contains "=") {
+        ($lastArg | split row -n 2 "=" | first) + "="
+    } else {
+        ""
+    }
+
+    let result = run-external ($spans | first) "%[3]s" ...$args | complete
+
+    # Some programs may output extra empty lines after the directive.
+    # Let's ignore them or else it will break completion.
+    # Ref: https://github.com/spf13/cobra/issues/1279
+    let lines = $result.stdout | lines | where {|line| ($line | str trim) != "" }
+    if ($lines | is-empty) {
+        # No completions, probably due to a failure; do file completion in case it helps
+        return null
+    }
+
+    # The directive is the last line and has the format :<directive>
+    let directive = $lines | last | str substring 1.. | into int
+    if ($directive | bits and $ShellCompDirectiveError) != 0 {
+        # Might as well do file completion, in case it helps
+        return null
+    }
+    if ($directive | bits and $ShellCompDirectiveFilterFileExt) != 0 or ($directive | bits and $ShellCompDirectiveFilterDirs) != 0 {
+        # File extension filtering and directory filtering are not supported; do full file completion instead
+        return null
+    }
+
+    let entries = $lines | drop 1
+    let activeHelp = $entries | where {|line| $line | str starts-with "%[10]s" }
+    mut comps = $entries | where {|line| not ($line | str starts-with "%[10]s") } | each {|line|
+        let parts = $line | split row -n 2 "\t"
+        let description = if ($parts | length) > 1 { $parts | last } else { "" }
+        {value: ($flagPrefix + ($parts | first)), description: $description}
+    }
+
+    if ($directive | bits and $ShellCompDirectiveKeepOrder) == 0 {
+        $comps = $comps | sort-by value
+    }
+
+    if ($comps | length) == 1 and ($directive | bits and $ShellCompDirectiveNoSpace) != 0 {
+        # To support the "nospace" directive we trick the shell by outputting
+        # an extra, longer completion so the single choice is not accepted.
+        let comp = $comps | first
+        $comps = $comps | append {value: ($comp.value + "."), description: $comp.description}
+    }
+
+    # ActiveHelp messages are shown as choices which leave the command-line unchanged
+    let helpEntries = $activeHelp | each {|line|
+        {value: $lastArg, description: ($line | str replace "%[10]s" "")}
+    }
+
+    if ($comps | is-empty) and ($directive | bits and $ShellCompDirectiveNoFileComp) == 0 {
+        # To be consistent with other shells, we only trigger file
+        # completion when there are no other completions
+        if ($helpEntries | is-empty) {
+            return null
+        }
+    }
+
+    $comps | append $helpEntries
+}
+
+# Register the completer, keeping any external completer already configured
+# so that it keeps handling the other programs.
+let __%[1]s_previous_completer = $env.config?.completions?.external?.completer?
+$env.config.completions.external.enable = true
+$env.config.completions.external.completer = {|spans: list<string>|
+    if ($spans | first) == "%[2]s" {
+        do $__%[1]s_completer $spans
+    } else if $__%[1]s_previous_completer != null {
+        do $__%[1]s_previous_completer $spans
+    }
+}
+`, nameForVar, name, compCmd,
+		ShellCompDirectiveError, ShellCompDirectiveNoSpace, ShellCompDirectiveNoFileComp,
+		ShellCompDirectiveFilterFileExt, ShellCompDirectiveFilterDirs, ShellCompDirectiveKeepOrder,
+		activeHelpMarker))
+}
+
+// GenNushellCompletion generates nushell completion file and writes to the passed writer.
+func (c *Command) GenNushellCompletion(w io.Writer, includeDesc bool) error {
+	buf := new(bytes.Buffer)
+	genNushellComp(buf, c.Name(), includeDesc)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// GenNushellCompletionFile generates nushell completion file.
+func (c *Command) GenNushellCompletionFile(filename string, includeDesc bool) error {
+	outFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	return c.GenNushellCompletion(outFile, includeDesc)
+}
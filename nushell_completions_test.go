@@ -0,0 +1,118 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestCompleteNoDesCmdInNushellScript(t *testing.T) {
+	rootCmd := &Command{Use: "root", Args: NoArgs, Run: emptyRun}
+	child := &Command{
+		Use:               "child",
+		ValidArgsFunction: validArgsFunc,
+		Run:               emptyRun,
+	}
+	rootCmd.AddCommand(child)
+
+	buf := new(bytes.Buffer)
+	assertNoErr(t, rootCmd.GenNushellCompletion(buf, false))
+	output := buf.String()
+
+	check(t, output, ShellCompNoDescRequestCmd)
+}
+
+func TestCompleteCmdInNushellScript(t *testing.T) {
+	rootCmd := &Command{Use: "root", Args: NoArgs, Run: emptyRun}
+	child := &Command{
+		Use:               "child",
+		ValidArgsFunction: validArgsFunc,
+		Run:               emptyRun,
+	}
+	rootCmd.AddCommand(child)
+
+	buf := new(bytes.Buffer)
+	assertNoErr(t, rootCmd.GenNushellCompletion(buf, true))
+	output := buf.String()
+
+	check(t, output, ShellCompRequestCmd)
+	checkOmit(t, output, ShellCompNoDescRequestCmd)
+}
+
+func TestNushellProgWithDash(t *testing.T) {
+	rootCmd := &Command{Use: "root-dash", Args: NoArgs, Run: emptyRun}
+	buf := new(bytes.Buffer)
+	assertNoErr(t, rootCmd.GenNushellCompletion(buf, false))
+	output := buf.String()
+
+	// Variable names should have replaced the '-'
+	check(t, output, "$__root_dash_completer")
+	checkOmit(t, output, "$__root-dash_completer")
+
+	// The command name should not have replaced the '-'
+	check(t, output, `== "root-dash"`)
+	checkOmit(t, output, `== "root_dash"`)
+}
+
+func TestNushellProgWithColon(t *testing.T) {
+	rootCmd := &Command{Use: "root:colon", Args: NoArgs, Run: emptyRun}
+	buf := new(bytes.Buffer)
+	assertNoErr(t, rootCmd.GenNushellCompletion(buf, false))
+	output := buf.String()
+
+	// Variable names should have replaced the ':'
+	check(t, output, "$__root_colon_completer")
+	checkOmit(t, output, "$__root:colon_completer")
+
+	// The command name should not have replaced the ':'
+	check(t, output, `== "root:colon"`)
+	checkOmit(t, output, `== "root_colon"`)
+}
+
+func TestNushellCompletionDirectives(t *testing.T) {
+	c := &Command{Use: "c", Run: emptyRun}
+
+	buf := new(bytes.Buffer)
+	assertNoErr(t, c.GenNushellCompletion(buf, true))
+	output := buf.String()
+
+	check(t, output, fmt.Sprintf("let ShellCompDirectiveKeepOrder = %d", ShellCompDirectiveKeepOrder))
+	check(t, output, fmt.Sprintf("let ShellCompDirectiveNoSpace = %d", ShellCompDirectiveNoSpace))
+	// ActiveHelp is supported so it must not be disabled
+	check(t, output, activeHelpMarker)
+	checkOmit(t, output, fmt.Sprintf("%s=0", activeHelpEnvVar(c.Name())))
+}
+
+func TestGenNushellCompletionFile(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "cobra-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	rootCmd := &Command{Use: "root", Args: NoArgs, Run: emptyRun}
+	child := &Command{
+		Use:               "child",
+		ValidArgsFunction: validArgsFunc,
+		Run:               emptyRun,
+	}
+	rootCmd.AddCommand(child)
+
+	assertNoErr(t, rootCmd.GenNushellCompletionFile(tmpFile.Name(), false))
+}
@@ -0,0 +1,247 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Options configures a tree of commands independently of the package-level configuration,
+// so that several trees can be configured differently, and executed concurrently, in the
+// same program.  Set them on the root command with SetOptions; the subcommands inherit them.
+//
+// NewOptions returns Options initialized with the package-level configuration.  The zero
+// value can also be used: its Enable* fields are false, and its template functions are
+// those of the package when it is first used.
+type Options struct {
+	// templateFuncsVersion is incremented when template functions are added, so that the
+	// templates parsed with the previous functions are parsed again.  Access it atomically;
+	// it is the first field to be 64-bit aligned on 32-bit platforms.
+	templateFuncsVersion uint64
+
+	// EnablePrefixMatching replaces the package-level EnablePrefixMatching.
+	EnablePrefixMatching bool
+	// EnableCommandSorting replaces the package-level EnableCommandSorting.
+	EnableCommandSorting bool
+	// EnableCaseInsensitive replaces the package-level EnableCaseInsensitive.
+	EnableCaseInsensitive bool
+	// EnableTraverseRunHooks replaces the package-level EnableTraverseRunHooks.
+	EnableTraverseRunHooks bool
+	// MousetrapHelpText replaces the package-level MousetrapHelpText.
+	MousetrapHelpText string
+	// MousetrapDisplayDuration replaces the package-level MousetrapDisplayDuration.
+	MousetrapDisplayDuration time.Duration
+
+	templateFuncs     template.FuncMap
+	templateFuncsOnce sync.Once
+	initializers      []func()
+	finalizers        []func()
+
+	// flagCompletionFunctions are the completion functions registered for the flags of the tree.
+	// Make sure to use flagCompletionMutex before you try to read and write from it.
+	flagCompletionFunctions map[*pflag.Flag]CompletionFunc
+	flagCompletionMutex     sync.RWMutex
+}
+
+// packageOptions are the options of the commands without Options.  Their configuration is
+// the package-level one, and they hold the completion functions registered for the flags.
+var packageOptions = &Options{}
+
+// NewOptions returns Options initialized with the current package-level configuration:
+// the values of the Enable* and Mousetrap* variables, the template functions added with
+// AddTemplateFunc, and the functions registered with OnInitialize and OnFinalize.
+func NewOptions() *Options {
+	o := &Options{
+		EnablePrefixMatching:     EnablePrefixMatching,
+		EnableCommandSorting:     EnableCommandSorting,
+		EnableCaseInsensitive:    EnableCaseInsensitive,
+		EnableTraverseRunHooks:   EnableTraverseRunHooks,
+		MousetrapHelpText:        MousetrapHelpText,
+		MousetrapDisplayDuration: MousetrapDisplayDuration,
+		initializers:             append([]func(){}, initializers...),
+		finalizers:               append([]func(){}, finalizers...),
+	}
+	o.funcs()
+	return o
+}
+
+// AddTemplateFunc adds a template function that's available to Usage and Help
+// template generation of the commands using these options.
+func (o *Options) AddTemplateFunc(name string, tmplFunc interface{}) {
+	o.funcs()[name] = tmplFunc
+	atomic.AddUint64(&o.templateFuncsVersion, 1)
+}
+
+// AddTemplateFuncs adds multiple template functions that are available to Usage and
+// Help template generation of the commands using these options.
+func (o *Options) AddTemplateFuncs(tmplFuncs template.FuncMap) {
+	funcs := o.funcs()
+	for k, v := range tmplFuncs {
+		funcs[k] = v
+	}
+	atomic.AddUint64(&o.templateFuncsVersion, 1)
+}
+
+// OnInitialize sets the passed functions to be run when the Execute method
+// of a command using these options is called.
+func (o *Options) OnInitialize(y ...func()) {
+	o.initializers = append(o.initializers, y...)
+}
+
+// OnFinalize sets the passed functions to be run when the Execute method
+// of a command using these options is terminated.
+func (o *Options) OnFinalize(y ...func()) {
+	o.finalizers = append(o.finalizers, y...)
+}
+
+// The following methods return the configuration of o, which is the package-level
+// configuration for packageOptions.
+
+func (o *Options) prefixMatching() bool {
+	if o == packageOptions {
+		return EnablePrefixMatching
+	}
+	return o.EnablePrefixMatching
+}
+
+func (o *Options) commandSorting() bool {
+	if o == packageOptions {
+		return EnableCommandSorting
+	}
+	return o.EnableCommandSorting
+}
+
+func (o *Options) caseInsensitive() bool {
+	if o == packageOptions {
+		return EnableCaseInsensitive
+	}
+	return o.EnableCaseInsensitive
+}
+
+func (o *Options) traverseRunHooks() bool {
+	if o == packageOptions {
+		return EnableTraverseRunHooks
+	}
+	return o.EnableTraverseRunHooks
+}
+
+func (o *Options) mousetrap() (string, time.Duration) {
+	if o == packageOptions {
+		return MousetrapHelpText, MousetrapDisplayDuration
+	}
+	return o.MousetrapHelpText, o.MousetrapDisplayDuration
+}
+
+func (o *Options) initializeFuncs() []func() {
+	if o == packageOptions {
+		return initializers
+	}
+	return o.initializers
+}
+
+func (o *Options) finalizeFuncs() []func() {
+	if o == packageOptions {
+		return finalizers
+	}
+	return o.finalizers
+}
+
+// funcs returns the template functions of o.  Options without template functions
+// get a copy of the package-level ones.
+func (o *Options) funcs() template.FuncMap {
+	if o == packageOptions {
+		return templateFuncs
+	}
+	o.templateFuncsOnce.Do(func() {
+		o.templateFuncs = make(template.FuncMap, len(templateFuncs))
+		for k, v := range templateFuncs {
+			o.templateFuncs[k] = v
+		}
+	})
+	return o.templateFuncs
+}
+
+// registerFlagCompletionFunc registers f as the completion function of flag.
+func (o *Options) registerFlagCompletionFunc(flag *pflag.Flag, f CompletionFunc) error {
+	o.flagCompletionMutex.Lock()
+	defer o.flagCompletionMutex.Unlock()
+
+	if _, exists := o.flagCompletionFunctions[flag]; exists {
+		return fmt.Errorf("RegisterFlagCompletionFunc: flag '%s' already registered", flag.Name)
+	}
+	if o.flagCompletionFunctions == nil {
+		o.flagCompletionFunctions = map[*pflag.Flag]CompletionFunc{}
+	}
+	o.flagCompletionFunctions[flag] = f
+	return nil
+}
+
+// flagCompletionFunc returns the completion function registered for flag.  Functions registered
+// before the options were set on the tree are found in the package-level registry.
+func (o *Options) flagCompletionFunc(flag *pflag.Flag) (CompletionFunc, bool) {
+	o.flagCompletionMutex.RLock()
+	completionFunc, exists := o.flagCompletionFunctions[flag]
+	o.flagCompletionMutex.RUnlock()
+	if exists || o == packageOptions {
+		return completionFunc, exists
+	}
+	return packageOptions.flagCompletionFunc(flag)
+}
+
+// completedFlags returns the flags with a registered completion function, including
+// those found in the package-level registry.
+func (o *Options) completedFlags() []*pflag.Flag {
+	var flags []*pflag.Flag
+	o.flagCompletionMutex.RLock()
+	for flag := range o.flagCompletionFunctions {
+		flags = append(flags, flag)
+	}
+	o.flagCompletionMutex.RUnlock()
+	if o == packageOptions {
+		return flags
+	}
+	return append(flags, packageOptions.completedFlags()...)
+}
+
+// SetOptions sets the options of the command and its subcommands, instead of the
+// package-level configuration.  It is typically called on the root command.
+func (c *Command) SetOptions(o *Options) {
+	c.options = o
+}
+
+// Options returns the options set with SetOptions on the command or its closest parent,
+// or nil if the command uses the package-level configuration.
+func (c *Command) Options() *Options {
+	for p := c; p != nil; p = p.Parent() {
+		if p.options != nil {
+			return p.options
+		}
+	}
+	return nil
+}
+
+// opts returns the options which apply to the command.
+func (c *Command) opts() *Options {
+	if o := c.Options(); o != nil {
+		return o
+	}
+	return packageOptions
+}
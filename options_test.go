@@ -0,0 +1,296 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewOptionsDefaults(t *testing.T) {
+	o := NewOptions()
+	if o.EnablePrefixMatching != defaultPrefixMatching ||
+		o.EnableCommandSorting != defaultCommandSorting ||
+		o.EnableCaseInsensitive != defaultCaseInsensitive ||
+		o.EnableTraverseRunHooks != defaultTraverseRunHooks {
+		t.Errorf("Expected the default configuration, got %+v", o)
+	}
+	if o.MousetrapHelpText != MousetrapHelpText || o.MousetrapDisplayDuration != MousetrapDisplayDuration {
+		t.Errorf("Expected the mousetrap configuration to be copied, got %+v", o)
+	}
+	if _, ok := o.templateFuncs["rpad"]; !ok {
+		t.Error("Expected the default template functions to be copied")
+	}
+}
+
+func TestOptionsZeroValue(t *testing.T) {
+	opts := &Options{}
+	opts.AddTemplateFunc("shout", strings.ToUpper)
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.Flags().String("color", "", "")
+	rootCmd.SetOptions(opts)
+	rootCmd.SetUsageTemplate(`{{shout .Name}}{{rpad "" 1}}`)
+
+	assertNoErr(t, rootCmd.RegisterFlagCompletionFunc("color", FixedCompletions([]string{"red"}, ShellCompDirectiveNoFileComp)))
+	if _, exists := rootCmd.GetFlagCompletionFunc("color"); !exists {
+		t.Error("Expected the completion function to be registered in the options")
+	}
+	if usage := rootCmd.UsageString(); usage != "ROOT " {
+		t.Errorf("Expected the template functions of the package and of the options, got %q", usage)
+	}
+}
+
+func TestOptionsPackageLevel(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	if allocs := testing.AllocsPerRun(10, func() { rootCmd.opts() }); allocs != 0 {
+		t.Errorf("Expected the package-level options not to be allocated, got %v allocations", allocs)
+	}
+
+	EnablePrefixMatching = true
+	defer func() { EnablePrefixMatching = defaultPrefixMatching }()
+	if !rootCmd.opts().prefixMatching() {
+		t.Error("Expected the package-level configuration to be used")
+	}
+}
+
+func TestOptionsInheritance(t *testing.T) {
+	rootCmd := &Command{Use: "root"}
+	childCmd := &Command{Use: "child"}
+	rootCmd.AddCommand(childCmd)
+
+	if childCmd.Options() != nil {
+		t.Error("Expected no options by default")
+	}
+
+	o := NewOptions()
+	rootCmd.SetOptions(o)
+	if childCmd.Options() != o {
+		t.Error("Expected the child command to inherit the options of the root")
+	}
+}
+
+func TestOptionsCommandMatching(t *testing.T) {
+	newTree := func(o *Options) *Command {
+		rootCmd := &Command{Use: "root", Run: emptyRun}
+		rootCmd.AddCommand(&Command{Use: "child", Run: emptyRun})
+		rootCmd.SetOptions(o)
+		return rootCmd
+	}
+
+	prefix := NewOptions()
+	prefix.EnablePrefixMatching = true
+	caseInsensitive := NewOptions()
+	caseInsensitive.EnableCaseInsensitive = true
+
+	testcases := []struct {
+		desc    string
+		options *Options
+		arg     string
+		found   bool
+	}{
+		{desc: "default exact", options: NewOptions(), arg: "child", found: true},
+		{desc: "default prefix", options: NewOptions(), arg: "chi", found: false},
+		{desc: "prefix matching", options: prefix, arg: "chi", found: true},
+		{desc: "default case", options: NewOptions(), arg: "CHILD", found: false},
+		{desc: "case insensitive", options: caseInsensitive, arg: "CHILD", found: true},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			cmd, _, err := newTree(tc.options).Find([]string{tc.arg})
+			found := err == nil && cmd.Name() == "child"
+			if found != tc.found {
+				t.Errorf("Expected %q to be found: %v, got command %v and error %v", tc.arg, tc.found, cmd.Name(), err)
+			}
+		})
+	}
+}
+
+func TestOptionsCommandSorting(t *testing.T) {
+	o := NewOptions()
+	o.EnableCommandSorting = false
+	rootCmd := &Command{Use: "root"}
+	rootCmd.SetOptions(o)
+	rootCmd.AddCommand(&Command{Use: "b"}, &Command{Use: "a"})
+
+	var names []string
+	for _, c := range rootCmd.Commands() {
+		names = append(names, c.Name())
+	}
+	if expected := []string{"b", "a"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected commands %v, got %v", expected, names)
+	}
+}
+
+func TestOptionsTraverseRunHooks(t *testing.T) {
+	var hooks []string
+	o := NewOptions()
+	o.EnableTraverseRunHooks = true
+
+	rootCmd := &Command{
+		Use:              "root",
+		PersistentPreRun: func(*Command, []string) { hooks = append(hooks, "root") },
+	}
+	childCmd := &Command{
+		Use:              "child",
+		PersistentPreRun: func(*Command, []string) { hooks = append(hooks, "child") },
+		Run:              emptyRun,
+	}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.SetOptions(o)
+
+	if _, err := executeCommand(rootCmd, "child"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"root", "child"}; !reflect.DeepEqual(hooks, expected) {
+		t.Errorf("Expected hooks %v, got %v", expected, hooks)
+	}
+	if EnableTraverseRunHooks {
+		t.Error("Expected the package-level configuration to be left unchanged")
+	}
+}
+
+func TestOptionsInitializersAndFinalizers(t *testing.T) {
+	var calls []string
+	o := NewOptions()
+	o.OnInitialize(func() { calls = append(calls, "initialize") })
+	o.OnFinalize(func() { calls = append(calls, "finalize") })
+
+	rootCmd := &Command{
+		Use: "root",
+		Run: func(*Command, []string) { calls = append(calls, "run") },
+	}
+	rootCmd.SetOptions(o)
+	otherCmd := &Command{Use: "other", Run: emptyRun}
+
+	if _, err := executeCommand(rootCmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := executeCommand(otherCmd); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"initialize", "run", "finalize"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, calls)
+	}
+}
+
+func TestOptionsTemplateFuncs(t *testing.T) {
+	o := NewOptions()
+	o.AddTemplateFunc("shout", strings.ToUpper)
+
+	rootCmd := &Command{Use: "root", Short: "quiet", Run: emptyRun}
+	rootCmd.SetOptions(o)
+	rootCmd.SetHelpTemplate(`{{shout .Short}}`)
+
+	output, err := executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "QUIET")
+	if _, ok := templateFuncs["shout"]; ok {
+		t.Error("Expected the package-level template functions to be left unchanged")
+	}
+}
+
+func TestOptionsTemplateFuncsScope(t *testing.T) {
+	newTree := func(mark func(string) string) *Command {
+		o := NewOptions()
+		o.AddTemplateFunc("mark", mark)
+		rootCmd := &Command{Use: "root", Short: "short", Run: emptyRun}
+		rootCmd.SetOptions(o)
+		return rootCmd
+	}
+	upperCmd := newTree(strings.ToUpper)
+	bracketCmd := newTree(func(s string) string { return "[" + s + "]" })
+
+	// The template is executed with the functions of the tree of the command it is executed with
+	childCmd := &Command{Use: "child", Short: "child", Run: emptyRun}
+	upperCmd.AddCommand(childCmd)
+	childCmd.SetHelpTemplate(`{{mark .Short}}`)
+
+	output, err := executeCommand(upperCmd, "child", "--help")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output != "CHILD" {
+		t.Errorf("Expected the functions of the first tree, got %q", output)
+	}
+
+	upperCmd.RemoveCommand(childCmd)
+	bracketCmd.AddCommand(childCmd)
+	output, err = executeCommand(bracketCmd, "child", "--help")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output != "[child]" {
+		t.Errorf("Expected the functions of the second tree, got %q", output)
+	}
+
+	otherCmd := &Command{Use: "other"}
+	otherCmd.SetHelpTemplate(`{{mark .Short}}`)
+	if err := otherCmd.ValidateTemplates(); err == nil {
+		t.Error("Expected the functions of a tree to be unknown outside of it")
+	}
+}
+
+func TestOptionsFlagCompletionFunctions(t *testing.T) {
+	newTree := func(values ...string) *Command {
+		rootCmd := &Command{Use: "root", Run: emptyRun}
+		rootCmd.SetOptions(NewOptions())
+		rootCmd.Flags().String("color", "", "")
+		assertNoErr(t, rootCmd.RegisterFlagCompletionFunc("color", FixedCompletions(values, ShellCompDirectiveNoFileComp)))
+		return rootCmd
+	}
+
+	for _, values := range [][]string{{"red", "green"}, {"blue"}} {
+		values := values
+		t.Run(strings.Join(values, ","), func(t *testing.T) {
+			t.Parallel()
+			rootCmd := newTree(values...)
+			output, err := executeCommand(rootCmd, ShellCompNoDescRequestCmd, "--color", "")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			expected := strings.Join(append(values, ":4", "Completion ended with directive: ShellCompDirectiveNoFileComp", ""), "\n")
+			if output != expected {
+				t.Errorf("expected: %q, got: %q", expected, output)
+			}
+
+			packageOptions.flagCompletionMutex.RLock()
+			defer packageOptions.flagCompletionMutex.RUnlock()
+			for f := range packageOptions.flagCompletionFunctions {
+				if f.Name == "color" {
+					t.Error("Expected the package-level registry to be left unchanged")
+				}
+			}
+		})
+	}
+}
+
+func TestOptionsFlagCompletionRegisteredBeforeOptions(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.Flags().String("shape", "", "")
+	assertNoErr(t, rootCmd.RegisterFlagCompletionFunc("shape", FixedCompletions([]string{"round"}, ShellCompDirectiveNoFileComp)))
+	rootCmd.SetOptions(NewOptions())
+
+	if _, exists := rootCmd.GetFlagCompletionFunc("shape"); !exists {
+		t.Error("Expected the completion function registered before the options to be found")
+	}
+	if err := rootCmd.RegisterFlagCompletionFunc("shape", nil); err == nil {
+		t.Error("Expected an error when registering the completion function twice")
+	}
+}
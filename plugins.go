@@ -0,0 +1,219 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPluginGroupID    = "plugins"
+	defaultPluginGroupTitle = "Plugin Commands:"
+)
+
+// PluginOptions configures the discovery of plugins by EnablePlugins.
+type PluginOptions struct {
+	// Dirs are the directories searched for plugins, before the directories of the PATH.
+	Dirs []string
+	// IgnorePath disables the search of plugins in the directories of the PATH.
+	IgnorePath bool
+	// GroupID is the ID of the group of the plugin commands in the help.  It defaults
+	// to "plugins"; the group is added with the title "Plugin Commands:" if the command
+	// does not have it.
+	GroupID string
+}
+
+// EnablePlugins adds a subcommand to c for each executable named "<name>-<subcommand>"
+// found in the directories of opts and of the PATH, where <name> is the name of c.
+// For instance, the executable "myapp-deploy" is run by "myapp deploy".
+//
+// The arguments are passed unchanged to the plugin, which uses the input and outputs of
+// the command, and its exit code is the exit code of the command (see ExitCode).  The shell
+// completion of the arguments is delegated to the __complete command of the plugin, so
+// plugins written with Cobra are completed like the other subcommands.
+//
+// Executables named like an existing subcommand are ignored; when several directories
+// contain the same plugin, the first one wins.
+func (c *Command) EnablePlugins(opts PluginOptions) {
+	// Do not append to the slice of the caller
+	dirs := append([]string{}, opts.Dirs...)
+	if !opts.IgnorePath {
+		dirs = append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+	}
+	groupID := opts.GroupID
+	if groupID == "" {
+		groupID = defaultPluginGroupID
+	}
+
+	prefix := c.Name() + "-"
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name(), prefix)
+			if !ok || c.hasSubCommand(name) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutableFile(path) {
+				continue
+			}
+			if !c.ContainsGroup(groupID) {
+				c.AddGroup(&Group{ID: groupID, Title: defaultPluginGroupTitle})
+			}
+			c.AddCommand(newPluginCmd(name, path, groupID))
+		}
+	}
+}
+
+// hasSubCommand returns whether c has a subcommand with the given name or alias.
+func (c *Command) hasSubCommand(name string) bool {
+	for _, cmd := range c.commands {
+		if cmd.Name() == name || cmd.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginName returns the name of the subcommand run by the executable file,
+// if it is a plugin, i.e. if its name starts with prefix.
+func pluginName(file, prefix string) (string, bool) {
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(file))
+		if !stringInSlice(ext, windowsExecutableExts()) {
+			return "", false
+		}
+		file = strings.TrimSuffix(file, filepath.Ext(file))
+	}
+	if !strings.HasPrefix(file, prefix) || len(file) == len(prefix) {
+		return "", false
+	}
+	return file[len(prefix):], true
+}
+
+// windowsExecutableExts returns the extensions of the executable files on Windows.
+func windowsExecutableExts() []string {
+	pathExt := os.Getenv("PATHEXT")
+	if pathExt == "" {
+		return []string{".com", ".exe", ".bat", ".cmd"}
+	}
+	return strings.Split(strings.ToLower(pathExt), string(filepath.ListSeparator))
+}
+
+// isExecutableFile returns whether path is a regular file which can be executed.
+func isExecutableFile(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	// On Windows, the extension makes a file executable
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0o111 != 0
+}
+
+// newPluginCmd returns the subcommand running the plugin at path.
+func newPluginCmd(name, path, groupID string) *Command {
+	return &Command{
+		Use:                name,
+		Short:              fmt.Sprintf("Run the %s plugin", filepath.Base(path)),
+		GroupID:            groupID,
+		DisableFlagParsing: true,
+		// The plugin reports its own errors
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *Command, args []string) error {
+			return runExecutable(cmd, path, args)
+		},
+		ValidArgsFunction: func(cmd *Command, args []string, toComplete string) ([]Completion, ShellCompDirective) {
+			return completePlugin(cmd, path, args, toComplete)
+		},
+	}
+}
+
+// pluginContext returns the context of cmd, which is not set when completing.
+func pluginContext(cmd *Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// runExecutable runs the executable at path with args, see runExecCmd.
+func runExecutable(cmd *Command, path string, args []string) error {
+	return runExecCmd(cmd, exec.CommandContext(pluginContext(cmd), path, args...))
+}
+
+// runExecCmd runs executable with the streams of cmd, and returns an ExitError
+// with its exit code if it fails.
+func runExecCmd(cmd *Command, executable *exec.Cmd) error {
+	executable.Stdin = cmd.InOrStdin()
+	executable.Stdout = cmd.OutOrStdout()
+	executable.Stderr = cmd.ErrOrStderr()
+
+	err := executable.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code := exitErr.ExitCode()
+		if code < 0 {
+			// Killed by a signal
+			code = ExitCodeError
+		}
+		return &ExitError{Code: code, Err: err}
+	}
+	if err != nil {
+		// The executable could not be started, so it cannot have reported the error
+		cmd.PrintErrln(cmd.ErrPrefix(), err.Error())
+	}
+	return err
+}
+
+// completePlugin delegates the completion of args to the __complete command of the plugin at path.
+func completePlugin(cmd *Command, path string, args []string, toComplete string) ([]Completion, ShellCompDirective) {
+	plugin := exec.CommandContext(pluginContext(cmd), path, append(append([]string{ShellCompRequestCmd}, args...), toComplete)...)
+	out, err := plugin.Output()
+	if err != nil {
+		return nil, ShellCompDirectiveDefault
+	}
+
+	// Only the last line is the directive: a completion can also start with ':'
+	lines := strings.Split(strings.TrimRight(string(out), "\r\n"), "\n")
+	directive := ShellCompDirectiveDefault
+	if last := lines[len(lines)-1]; strings.HasPrefix(last, ":") {
+		if d, err := strconv.Atoi(last[1:]); err == nil {
+			directive = ShellCompDirective(d)
+			lines = lines[:len(lines)-1]
+		}
+	}
+	var completions []Completion
+	for _, line := range lines {
+		if line = strings.TrimSuffix(line, "\r"); line != "" {
+			completions = append(completions, line)
+		}
+	}
+	return completions, directive
+}
@@ -0,0 +1,157 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writePlugin writes a shell script named file in dir.
+func writePlugin(t *testing.T, dir, file, script string, mode os.FileMode) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+	assertNoErr(t, os.WriteFile(filepath.Join(dir, file), []byte("#!/bin/sh\n"+script), mode))
+}
+
+func TestEnablePluginsDiscovery(t *testing.T) {
+	dir, otherDir := t.TempDir(), t.TempDir()
+	writePlugin(t, dir, "root-hello", "echo first\n", 0o755)
+	writePlugin(t, otherDir, "root-hello", "echo second\n", 0o755)
+	writePlugin(t, otherDir, "root-bye", "echo bye\n", 0o755)
+	writePlugin(t, dir, "root-child", "echo plugin\n", 0o755)
+	writePlugin(t, dir, "root-noexec", "echo noexec\n", 0o644)
+	writePlugin(t, dir, "root-", "echo empty\n", 0o755)
+	writePlugin(t, dir, "other-hello", "echo other\n", 0o755)
+	assertNoErr(t, os.Mkdir(filepath.Join(dir, "root-dir"), 0o755))
+
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(&Command{Use: "child", Run: emptyRun})
+	rootCmd.EnablePlugins(PluginOptions{Dirs: []string{dir, otherDir}, IgnorePath: true})
+
+	var names []string
+	for _, c := range rootCmd.Commands() {
+		names = append(names, c.Name())
+	}
+	if expected := []string{"bye", "child", "hello"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected commands %v, got %v", expected, names)
+	}
+
+	output, err := executeCommand(rootCmd, "hello")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output != "first\n" {
+		t.Errorf("Expected the plugin of the first directory to run, got %q", output)
+	}
+
+	output, err = executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "Plugin Commands:\n  bye         Run the root-bye plugin\n  hello       Run the root-hello plugin\n")
+}
+
+func TestEnablePluginsFromPath(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "root-hello", "echo hello\n", 0o755)
+	savedPath := os.Getenv("PATH")
+	defer os.Setenv("PATH", savedPath)
+	os.Setenv("PATH", strings.Join([]string{filepath.Join(dir, "missing"), dir}, string(filepath.ListSeparator)))
+
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddGroup(&Group{ID: "extensions", Title: "Extensions:"})
+	rootCmd.EnablePlugins(PluginOptions{GroupID: "extensions"})
+
+	output, err := executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "Extensions:\n  hello")
+	checkStringOmits(t, output, "Plugin Commands:")
+
+	rootCmd = &Command{Use: "root", Run: emptyRun}
+	rootCmd.EnablePlugins(PluginOptions{IgnorePath: true})
+	if rootCmd.HasSubCommands() {
+		t.Errorf("Expected the PATH to be ignored, got %v", rootCmd.Commands())
+	}
+
+	// The directories of the PATH must not be appended to the slice of the caller
+	dirs := make([]string, 1, 4)
+	dirs[0] = filepath.Join(dir, "missing")
+	rootCmd = &Command{Use: "root", Run: emptyRun}
+	rootCmd.EnablePlugins(PluginOptions{Dirs: dirs})
+	if spare := dirs[1:cap(dirs)]; !reflect.DeepEqual(spare, []string{"", "", ""}) {
+		t.Errorf("Expected the slice of the caller to be unchanged, got %v", spare)
+	}
+}
+
+func TestPluginForwarding(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "root-echo", `echo "args: $*"
+read line
+echo "stdin: $line"
+echo "oops" >&2
+exit 3
+`, 0o755)
+
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.EnablePlugins(PluginOptions{Dirs: []string{dir}, IgnorePath: true})
+	rootCmd.SetIn(strings.NewReader("input\n"))
+
+	output, err := executeCommand(rootCmd, "echo", "one", "--help", "-x")
+	if code := ExitCode(err); code != 3 {
+		t.Errorf("Expected the exit code of the plugin, got %d (%v)", code, err)
+	}
+	if expected := "args: one --help -x\nstdin: input\noops\n"; output != expected {
+		t.Errorf("Expected %q, got %q", expected, output)
+	}
+}
+
+func TestPluginCompletion(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "root-color", `if [ "$1" = "__complete" ]; then
+  shift
+  echo "red	args: $*"
+  echo ":8080	a completion starting with a colon"
+  echo "green"
+  echo ":4"
+  echo "Completion ended with directive: ShellCompDirectiveNoFileComp" >&2
+fi
+`, 0o755)
+
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.EnablePlugins(PluginOptions{Dirs: []string{dir}, IgnorePath: true})
+
+	output, err := executeCommand(rootCmd, ShellCompRequestCmd, "color", "--light", "r")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := strings.Join([]string{
+		"red\targs: --light r",
+		":8080\ta completion starting with a colon",
+		"green",
+		":4",
+		"Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	if output != expected {
+		t.Errorf("expected: %q, got: %q", expected, output)
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+// flagSensitiveAnnotation marks a flag whose value is not echoed when prompted.
+const flagSensitiveAnnotation = "cobra_annotation_flag_sensitive"
+
+// isInteractive returns whether in is a terminal, where the user can be prompted.
+// It is a variable for testing purposes.
+var isInteractive = func(in io.Reader) bool {
+	f, ok := in.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// MarkFlagSensitive marks the named flag as holding a secret, such as a password,
+// so that its value is not echoed when the user is prompted for it.  See PromptMissing.
+func (c *Command) MarkFlagSensitive(name string) error {
+	f := c.Flag(name)
+	if f == nil {
+		return fmt.Errorf("MarkFlagSensitive: flag '%s' does not exist", name)
+	}
+	setFlagAnnotation(f, flagSensitiveAnnotation, []string{"true"})
+	return nil
+}
+
+// byteReader reads one byte at a time, so that a bufio.Reader reading from it does
+// not consume the input following the answers, which is left to the command.
+type byteReader struct {
+	r io.Reader
+}
+
+func (b byteReader) Read(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:1]
+	}
+	return b.r.Read(p)
+}
+
+// prompter asks the user for the values of missing arguments and flags.
+type prompter struct {
+	cmd *Command
+	in  *bufio.Reader
+	out io.Writer
+	// fd is the file descriptor of the terminal, used to read sensitive values without echo.
+	fd int
+}
+
+// promptMissing prompts for the positional arguments and the required flags which
+// ValidateArgs and ValidateRequiredFlags would report as missing, when the input
+// is a terminal.  It returns the arguments completed with the values entered.
+// If the input ends, the values still missing are left to the validation.
+func (c *Command) promptMissing(args []string) ([]string, error) {
+	in := c.InOrStdin()
+	if !isInteractive(in) {
+		return args, nil
+	}
+	p := &prompter{cmd: c, in: bufio.NewReader(byteReader{in}), out: c.ErrOrStderr(), fd: -1}
+	if f, ok := in.(*os.File); ok {
+		p.fd = int(f.Fd())
+	}
+
+	var countErr *InvalidArgCountError
+	if err := c.ValidateArgs(args); errors.As(err, &countErr) && countErr.Received < countErr.Min {
+		for i := countErr.Received; i < countErr.Min; i++ {
+			value, err := p.promptArg(args, i)
+			if err == io.EOF {
+				return args, nil
+			}
+			if err != nil {
+				return args, err
+			}
+			args = append(args, value)
+		}
+	}
+
+	var flagsErr *RequiredFlagsError
+	if err := c.ValidateRequiredFlags(); errors.As(err, &flagsErr) {
+		for _, name := range flagsErr.Flags {
+			err := p.promptFlag(c.Flags().Lookup(name), args)
+			if err == io.EOF {
+				return args, nil
+			}
+			if err != nil {
+				return args, err
+			}
+		}
+	}
+	return args, nil
+}
+
+// promptArg prompts for the value of the positional argument at position i.
+func (p *prompter) promptArg(args []string, i int) (string, error) {
+	def, hasDef := p.cmd.argDefAt(i)
+	label := fmt.Sprintf("argument %d", i+1)
+	if hasDef {
+		label = def.Name
+	}
+	choices := p.argChoices(args)
+
+	for {
+		value, err := p.ask(label, def.Description, "", choices, false)
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			continue
+		}
+		if hasDef {
+			if _, err := def.parse(value); err != nil {
+				fmt.Fprintln(p.out, (&InvalidArgValueError{Name: def.Name, Value: value, Err: unwrapNumError(err)}).Error())
+				continue
+			}
+		}
+		return value, nil
+	}
+}
+
+// promptFlag prompts for the value of flag and sets it.
+func (p *prompter) promptFlag(f *flag.Flag, args []string) error {
+	sensitive := false
+	if values, ok := f.Annotations[flagSensitiveAnnotation]; ok && len(values) > 0 && values[0] == "true" {
+		sensitive = true
+	}
+	defValue := f.DefValue
+	if sensitive || defValue == "[]" {
+		defValue = ""
+	}
+	var choices []string
+	if !sensitive {
+		choices = p.flagChoices(f, args)
+	}
+
+	for {
+		value, err := p.ask("--"+f.Name, f.Usage, defValue, choices, sensitive)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			if defValue == "" {
+				continue
+			}
+			value = defValue
+		}
+		if err := p.cmd.Flags().Set(f.Name, value); err != nil {
+			fmt.Fprintf(p.out, "invalid value %q for --%s: %v\n", value, f.Name, err)
+			continue
+		}
+		return nil
+	}
+}
+
+// ask prints the prompt and reads the answer, which can also be the number of a choice.
+func (p *prompter) ask(label, usage, defValue string, choices []string, sensitive bool) (string, error) {
+	for i, choice := range choices {
+		fmt.Fprintf(p.out, "  %d) %s\n", i+1, choice)
+	}
+	prompt := label
+	if usage = strings.TrimSpace(usage); usage != "" {
+		prompt += " (" + usage + ")"
+	}
+	if defValue != "" {
+		prompt += " [" + defValue + "]"
+	}
+	fmt.Fprint(p.out, prompt+": ")
+
+	var value string
+	var err error
+	if sensitive && p.fd >= 0 {
+		value, err = p.readMasked()
+	} else {
+		value, err = p.readLine()
+	}
+	if err != nil {
+		return "", err
+	}
+	value = strings.TrimSpace(value)
+	if n, err := strconv.Atoi(value); err == nil && n >= 1 && n <= len(choices) {
+		return choices[n-1], nil
+	}
+	return value, nil
+}
+
+func (p *prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		return line, nil
+	}
+	if err == io.EOF {
+		fmt.Fprintln(p.out)
+	}
+	return line, err
+}
+
+// readMasked reads a line from the terminal without echoing it.
+func (p *prompter) readMasked() (string, error) {
+	restore, err := makeRaw(p.fd)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	var value []rune
+	for {
+		r, _, err := p.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case keyEnter, '\n':
+			fmt.Fprint(p.out, "\r\n")
+			return string(value), nil
+		case keyCtrlC:
+			fmt.Fprint(p.out, "^C\r\n")
+			return "", errors.New("interrupted")
+		case keyCtrlD:
+			if len(value) == 0 {
+				fmt.Fprint(p.out, "\r\n")
+				return "", io.EOF
+			}
+		case keyBackspace, keyDelete:
+			if len(value) > 0 {
+				value = value[:len(value)-1]
+			}
+		default:
+			value = append(value, r)
+		}
+	}
+}
+
+// argChoices returns the completions of the next positional argument, found like
+// the shell completion does.
+func (p *prompter) argChoices(args []string) []string {
+	c := p.cmd
+	if len(c.ValidArgs) > 0 {
+		// ValidArgs are only for the first argument
+		if len(args) > 0 {
+			return nil
+		}
+		return promptChoices(c.ValidArgs, ShellCompDirectiveDefault)
+	}
+	return p.choices(nil, args)
+}
+
+// flagChoices returns the completions of flag.
+func (p *prompter) flagChoices(f *flag.Flag, args []string) []string {
+	return p.choices(f, args)
+}
+
+// choices returns the completions of flag if it is not nil, or else of the next
+// positional argument.
+func (p *prompter) choices(f *flag.Flag, args []string) []string {
+	completionFn := p.cmd.completionFunc(f, len(args))
+	if completionFn == nil {
+		return nil
+	}
+	completions, directive := p.cmd.callCompletionFunc(completionFn, f, args, "")
+	return promptChoices(completions, directive)
+}
+
+// promptChoices returns the values of completions, without their descriptions
+// and the ActiveHelp messages.
+func promptChoices(completions []Completion, directive ShellCompDirective) []string {
+	if directive&ShellCompDirectiveError != 0 || directive&(ShellCompDirectiveFilterFileExt|ShellCompDirectiveFilterDirs) != 0 {
+		return nil
+	}
+	var choices []string
+	for _, comp := range completions {
+		if strings.HasPrefix(comp, activeHelpMarker) {
+			continue
+		}
+		choices = append(choices, strings.SplitN(comp, "\t", 2)[0])
+	}
+	return choices
+}
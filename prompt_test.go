@@ -0,0 +1,289 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// interactive makes the input of the commands look like a terminal during the test.
+func interactive(t *testing.T) {
+	saved := isInteractive
+	isInteractive = func(io.Reader) bool { return true }
+	t.Cleanup(func() { isInteractive = saved })
+}
+
+func executePromptCommand(cmd *Command, input string, args ...string) (string, error) {
+	buf := new(bytes.Buffer)
+	cmd.SetIn(strings.NewReader(input))
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return buf.String(), err
+}
+
+func TestPromptRequiredFlag(t *testing.T) {
+	interactive(t)
+	var name string
+	rootCmd := &Command{Use: "root", PromptMissing: true, Run: emptyRun}
+	rootCmd.Flags().StringVar(&name, "name", "", "the name")
+	assertNoErr(t, rootCmd.MarkFlagRequired("name"))
+
+	output, err := executePromptCommand(rootCmd, "\nbob\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name != "bob" {
+		t.Errorf("Expected the flag to be set to bob, got %q", name)
+	}
+	// An empty answer is asked again when there is no default value.
+	if expected := "--name (the name): --name (the name): "; output != expected {
+		t.Errorf("Expected output %q, got %q", expected, output)
+	}
+}
+
+func TestPromptFlagDefaultAndChoices(t *testing.T) {
+	interactive(t)
+	var color, size string
+	rootCmd := &Command{Use: "root", PromptMissing: true, Run: emptyRun}
+	rootCmd.Flags().StringVar(&color, "color", "", "the color")
+	rootCmd.Flags().StringVar(&size, "size", "medium", "the size")
+	assertNoErr(t, rootCmd.MarkFlagRequired("color"))
+	assertNoErr(t, rootCmd.MarkFlagRequired("size"))
+	assertNoErr(t, rootCmd.RegisterFlagCompletionFunc("color", func(cmd *Command, args []string, toComplete string) ([]Completion, ShellCompDirective) {
+		return AppendActiveHelp([]Completion{"red\tWarm", "blue\tCold"}, "pick one"), ShellCompDirectiveNoFileComp
+	}))
+
+	output, err := executePromptCommand(rootCmd, "2\n\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if color != "blue" || size != "medium" {
+		t.Errorf("Expected blue and medium, got %q and %q", color, size)
+	}
+	checkStringContains(t, output, "  1) red\n  2) blue\n--color (the color): ")
+	checkStringContains(t, output, "--size (the size) [medium]: ")
+}
+
+func TestPromptInvalidFlagValue(t *testing.T) {
+	interactive(t)
+	var count int
+	rootCmd := &Command{Use: "root", PromptMissing: true, Run: emptyRun}
+	rootCmd.Flags().IntVar(&count, "count", 0, "")
+	assertNoErr(t, rootCmd.MarkFlagRequired("count"))
+
+	output, err := executePromptCommand(rootCmd, "many\n3\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3, got %d", count)
+	}
+	checkStringContains(t, output, `invalid value "many" for --count`)
+}
+
+func TestPromptSensitiveFlag(t *testing.T) {
+	interactive(t)
+	var password string
+	rootCmd := &Command{Use: "root", PromptMissing: true, Run: emptyRun}
+	rootCmd.Flags().StringVar(&password, "password", "changeme", "")
+	assertNoErr(t, rootCmd.MarkFlagRequired("password"))
+	assertNoErr(t, rootCmd.MarkFlagSensitive("password"))
+	assertNoErr(t, rootCmd.RegisterFlagCompletionFunc("password", FixedCompletions([]string{"hunter2"}, ShellCompDirectiveNoFileComp)))
+
+	output, err := executePromptCommand(rootCmd, "s3cret\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if password != "s3cret" {
+		t.Errorf("Expected s3cret, got %q", password)
+	}
+	if expected := "--password: "; output != expected {
+		t.Errorf("Expected neither the default nor the choices to be shown, got %q", output)
+	}
+
+	if err := rootCmd.MarkFlagSensitive("unknown"); err == nil {
+		t.Error("Expected an error for an unknown flag")
+	}
+}
+
+func TestPromptArgDefs(t *testing.T) {
+	interactive(t)
+	var got []string
+	rootCmd := &Command{
+		Use:           "root",
+		PromptMissing: true,
+		ArgDefs: []ArgDef{
+			{Name: "SOURCE", Description: "the source"},
+			{Name: "COUNT", Type: ArgTypeInt},
+			{Name: "MODE", Type: ArgTypeEnum, Values: []string{"fast", "slow"}},
+			{Name: "EXTRA", Optional: true},
+		},
+		Run: func(cmd *Command, args []string) { got = args },
+	}
+
+	output, err := executePromptCommand(rootCmd, "two\n2\n1\n", "src")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"src", "2", "fast"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected args %v, got %v", expected, got)
+	}
+	if count, _ := rootCmd.GetArgInt("COUNT"); count != 2 {
+		t.Errorf("Expected the prompted argument to be retrieved, got %d", count)
+	}
+	checkStringContains(t, output, `invalid value "two" for argument COUNT`)
+	checkStringContains(t, output, "  1) fast\n  2) slow\nMODE: ")
+	if strings.Contains(output, "SOURCE") || strings.Contains(output, "EXTRA") {
+		t.Errorf("Expected only the missing required arguments to be prompted, got %q", output)
+	}
+}
+
+func TestPromptArgs(t *testing.T) {
+	interactive(t)
+	var got []string
+	rootCmd := &Command{
+		Use:           "root",
+		PromptMissing: true,
+		Args:          ExactArgs(2),
+		ValidArgs:     []string{"one\tThe first", "two"},
+		Run:           func(cmd *Command, args []string) { got = args },
+	}
+
+	output, err := executePromptCommand(rootCmd, "2\nthree\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"two", "three"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected args %v, got %v", expected, got)
+	}
+	// Like in the shell completion, ValidArgs are only for the first argument
+	checkStringContains(t, output, "  1) one\n  2) two\nargument 1: argument 2: ")
+}
+
+func TestPromptArgCompletionFunc(t *testing.T) {
+	interactive(t)
+	var got []string
+	rootCmd := &Command{
+		Use:           "root",
+		PromptMissing: true,
+		Args:          ExactArgs(2),
+		ValidArgsFunction: func(*Command, []string, string) ([]Completion, ShellCompDirective) {
+			return []Completion{"a", "b"}, ShellCompDirectiveNoFileComp
+		},
+		Run: func(cmd *Command, args []string) { got = args },
+	}
+	assertNoErr(t, rootCmd.RegisterArgCompletionFunc(1, FixedCompletions([]Completion{"c"}, ShellCompDirectiveNoFileComp)))
+
+	output, err := executePromptCommand(rootCmd, "2\n1\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"b", "c"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected args %v, got %v", expected, got)
+	}
+	checkStringContains(t, output, "  1) a\n  2) b\nargument 1:   1) c\nargument 2: ")
+}
+
+func TestPromptLeavesInput(t *testing.T) {
+	interactive(t)
+	var rest string
+	rootCmd := &Command{
+		Use:           "root",
+		PromptMissing: true,
+		Run: func(cmd *Command, args []string) {
+			data, _ := io.ReadAll(cmd.InOrStdin())
+			rest = string(data)
+		},
+	}
+	rootCmd.Flags().String("name", "", "")
+	assertNoErr(t, rootCmd.MarkFlagRequired("name"))
+
+	if _, err := executePromptCommand(rootCmd, "bob\nthe input of the command\n"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := "the input of the command\n"; rest != expected {
+		t.Errorf("Expected the command to read %q, got %q", expected, rest)
+	}
+}
+
+func TestPromptErrorRunsFinally(t *testing.T) {
+	interactive(t)
+	readErr := errors.New("broken input")
+	var finallyErr error
+	rootCmd := &Command{
+		Use:           "root",
+		PromptMissing: true,
+		Args:          ExactArgs(1),
+		Run:           emptyRun,
+		Finally: func(_ *Command, _ []string, err error) error {
+			finallyErr = err
+			return err
+		},
+	}
+	rootCmd.SetIn(iotest.ErrReader(readErr))
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetErr(io.Discard)
+	rootCmd.SetArgs([]string{})
+
+	if err := rootCmd.Execute(); err != readErr {
+		t.Errorf("Expected the read error, got %v", err)
+	}
+	if finallyErr != readErr {
+		t.Errorf("Expected the Finally hook to receive the read error, got %v", finallyErr)
+	}
+}
+
+func TestPromptEndOfInput(t *testing.T) {
+	interactive(t)
+	rootCmd := &Command{Use: "root", PromptMissing: true, Args: ExactArgs(1), Run: emptyRun}
+	rootCmd.Flags().String("name", "", "")
+	assertNoErr(t, rootCmd.MarkFlagRequired("name"))
+
+	_, err := executePromptCommand(rootCmd, "")
+	var countErr *InvalidArgCountError
+	if !errors.As(err, &countErr) {
+		t.Errorf("Expected the argument error when the input ends, got %v", err)
+	}
+}
+
+func TestPromptDisabled(t *testing.T) {
+	newCmd := func(prompt bool) *Command {
+		rootCmd := &Command{Use: "root", PromptMissing: prompt, Run: emptyRun}
+		rootCmd.Flags().String("name", "", "")
+		assertNoErr(t, rootCmd.MarkFlagRequired("name"))
+		return rootCmd
+	}
+	var flagsErr *RequiredFlagsError
+
+	// The input is not a terminal.
+	output, err := executePromptCommand(newCmd(true), "bob\n")
+	if !errors.As(err, &flagsErr) || strings.Contains(output, "--name:") {
+		t.Errorf("Expected no prompt when not interactive, got %v and %q", err, output)
+	}
+
+	interactive(t)
+	output, err = executePromptCommand(newCmd(false), "bob\n")
+	if !errors.As(err, &flagsErr) || strings.Contains(output, "--name:") {
+		t.Errorf("Expected no prompt when not enabled, got %v and %q", err, output)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"encoding/csv"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+// ResetState clears the state left by the executions of the command and its subcommands,
+// so that they can be executed again as if it were the first time:
+//   - the flags which have been set get back their default value, and are no longer Changed,
+//     except the map flags of pflag, such as StringToString: pflag offers no way to replace
+//     their value, and the next values would be added to the default one;
+//   - the arguments set with SetArgs are cleared, as well as the parsed positional arguments;
+//   - CalledAs returns an empty string;
+//   - the commands added by Cobra during the execution, such as the default help and
+//     completion commands, are removed;
+//   - the caches of local and inherited flags are cleared;
+//   - the context of the subcommands, inherited from the root during the execution, is cleared.
+//
+// The definition of the commands, such as their flags and hooks, is kept.  Call ResetState
+// on the root command between two executions, for instance in a REPL or in tests.
+func (c *Command) ResetState() {
+	resetFlagValues(c)
+	c.resetState(c)
+}
+
+func (c *Command) resetState(top *Command) {
+	c.args = nil
+	c.argValues = nil
+	c.commandCalledAs.name = ""
+	c.commandCalledAs.called = false
+	if c != top {
+		c.ctx = nil
+	}
+
+	for _, sub := range append([]*Command{}, c.commands...) {
+		if !sub.autoAdded {
+			sub.resetState(top)
+			continue
+		}
+		c.RemoveCommand(sub)
+		if sub == c.helpCommand {
+			c.helpCommand = nil
+		}
+	}
+
+	c.lflags = nil
+	c.iflags = nil
+	c.parentsPflags = nil
+}
+
+// resetFlagValues restores the default value of every flag of c and its sub-commands
+// which has been set, except the map flags.
+func resetFlagValues(c *Command) {
+	reset := func(f *flag.Flag) {
+		if !f.Changed || isMapFlag(f) {
+			return
+		}
+		if sv, ok := f.Value.(flag.SliceValue); ok {
+			_ = sv.Replace(readSliceDefValue(f.DefValue))
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	c.Flags().VisitAll(reset)
+	c.PersistentFlags().VisitAll(reset)
+	for _, sub := range c.commands {
+		resetFlagValues(sub)
+	}
+}
+
+// isMapFlag returns whether f is a map flag of pflag, whose Set adds to the map once
+// the flag has been set.
+func isMapFlag(f *flag.Flag) bool {
+	switch f.Value.Type() {
+	case "stringToString", "stringToInt", "stringToInt64":
+		return true
+	}
+	return false
+}
+
+// readSliceDefValue parses the default value of a slice flag, which is
+// formatted as a comma-separated list within square brackets.
+func readSliceDefValue(defValue string) []string {
+	defValue = strings.TrimSuffix(strings.TrimPrefix(defValue, "["), "]")
+	if defValue == "" {
+		return []string{}
+	}
+	values, err := csv.NewReader(strings.NewReader(defValue)).Read()
+	if err != nil {
+		return strings.Split(defValue, ",")
+	}
+	return values
+}
@@ -0,0 +1,165 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestResetStateFlags(t *testing.T) {
+	var name string
+	var tags []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.PersistentFlags().StringVar(&name, "name", "default", "")
+	childCmd.Flags().StringSliceVar(&tags, "tag", []string{"a", "b"}, "")
+
+	if _, err := executeCommand(rootCmd, "child", "--name", "other", "--tag", "c"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name != "other" || !reflect.DeepEqual(tags, []string{"c"}) {
+		t.Fatalf("Unexpected flag values %q and %v", name, tags)
+	}
+
+	rootCmd.ResetState()
+
+	if name != "default" || !reflect.DeepEqual(tags, []string{"a", "b"}) {
+		t.Errorf("Expected the default flag values, got %q and %v", name, tags)
+	}
+	if childCmd.Flags().Changed("tag") || rootCmd.PersistentFlags().Changed("name") {
+		t.Error("Expected the flags not to be changed anymore")
+	}
+
+	if _, err := executeCommand(rootCmd, "child"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name != "default" || !reflect.DeepEqual(tags, []string{"a", "b"}) {
+		t.Errorf("Expected the default flag values after a new execution, got %q and %v", name, tags)
+	}
+}
+
+func TestResetStateMapFlags(t *testing.T) {
+	var labels map[string]string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.Flags().StringToStringVar(&labels, "labels", map[string]string{"a": "1"}, "")
+
+	if _, err := executeCommand(rootCmd, "--labels", "b=2"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rootCmd.ResetState()
+
+	// pflag cannot replace the value of a map flag, so it is left as it is
+	if expected := map[string]string{"b": "2"}; !reflect.DeepEqual(labels, expected) {
+		t.Errorf("Expected the map flag to keep its value %v, got %v", expected, labels)
+	}
+	if !rootCmd.Flags().Changed("labels") {
+		t.Error("Expected the map flag to still be changed")
+	}
+}
+
+func TestResetStateCalledAsAndArgs(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{Use: "child", Aliases: []string{"kid"}, Args: ArbitraryArgs, Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+
+	if _, err := executeCommand(rootCmd, "kid", "one"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if childCmd.CalledAs() != "kid" {
+		t.Fatalf("Expected CalledAs to be kid, got %q", childCmd.CalledAs())
+	}
+
+	rootCmd.ResetState()
+
+	if childCmd.CalledAs() != "" {
+		t.Errorf("Expected CalledAs to be empty, got %q", childCmd.CalledAs())
+	}
+	if rootCmd.args != nil || childCmd.argValues != nil {
+		t.Errorf("Expected the arguments to be cleared, got %v and %v", rootCmd.args, childCmd.argValues)
+	}
+}
+
+func TestResetStateAutoAddedCommands(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{Use: "child", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+
+	if _, err := executeCommand(rootCmd, "child"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rootCmd.Commands()) != 3 {
+		t.Fatalf("Expected the help and completion commands to be added, got %v", rootCmd.Commands())
+	}
+
+	rootCmd.ResetState()
+
+	if commands := rootCmd.Commands(); len(commands) != 1 || commands[0] != childCmd {
+		t.Errorf("Expected only the child command to be left, got %v", commands)
+	}
+
+	// The removed commands are added again by the next execution.
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	if _, err := executeCommand(rootCmd, "child"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var names []string
+	for _, c := range rootCmd.Commands() {
+		names = append(names, c.Name())
+	}
+	if expected := []string{"child", "help"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected commands %v, got %v", expected, names)
+	}
+}
+
+func TestResetStateKeepsUserHelpCommand(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(&Command{Use: "child", Run: emptyRun})
+	helpCmd := &Command{Use: "help", Run: emptyRun}
+	rootCmd.SetHelpCommand(helpCmd)
+
+	if _, err := executeCommand(rootCmd, "child"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rootCmd.ResetState()
+
+	if rootCmd.helpCommand != helpCmd {
+		t.Error("Expected the help command set by the user to be kept")
+	}
+}
+
+func TestResetStateContext(t *testing.T) {
+	type key struct{}
+	var got interface{}
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{
+		Use: "child",
+		Run: func(cmd *Command, args []string) { got = cmd.Context().Value(key{}) },
+	}
+	rootCmd.AddCommand(childCmd)
+
+	for _, value := range []string{"first", "second"} {
+		rootCmd.SetArgs([]string{"child"})
+		if err := rootCmd.ExecuteContext(context.WithValue(context.Background(), key{}, value)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got != value {
+			t.Errorf("Expected the context value %q, got %v", value, got)
+		}
+		rootCmd.ResetState()
+	}
+}
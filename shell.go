@@ -0,0 +1,434 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+const (
+	shellCmdName            = "shell"
+	defaultShellHistorySize = 1000
+)
+
+// ShellOptions configures the interactive shell returned by NewShellCmd.
+type ShellOptions struct {
+	// Prompt is printed before each line. It defaults to the name of the root command followed by "> ".
+	Prompt string
+	// HistoryFile is the file where the lines entered are saved, so that they can be recalled
+	// in the next sessions.  The history is not saved if it is empty.
+	HistoryFile string
+	// HistorySize is the maximum number of lines kept in the history. It defaults to 1000.
+	HistorySize int
+}
+
+// NewShellCmd returns a "shell" command which opens an interactive prompt executing the
+// commands of its root, for instance "myapp shell".  Add it to your root command to enable it.
+//
+// When the input is a terminal, the prompt supports line editing, the history of the lines
+// entered, and completion with the TAB key, which behaves like the shell completion of the program.
+// The shell reads from and writes to the input and outputs of the command, see SetIn, SetOut and SetErr.
+// It keeps going when a command fails, and exits on "exit", "quit" or the end of the input (Ctrl-D).
+func NewShellCmd(opts ShellOptions) *Command {
+	return &Command{
+		Use:               shellCmdName,
+		Short:             "Start an interactive shell",
+		Long:              "Start an interactive shell to execute commands one after the other.\nType \"exit\" or press Ctrl-D to leave it.",
+		Args:              NoArgs,
+		ValidArgsFunction: NoFileCompletions,
+		RunE: func(cmd *Command, args []string) error {
+			return runShell(cmd, opts)
+		},
+	}
+}
+
+// shellLineReader reads the lines entered in the shell.
+type shellLineReader interface {
+	readLine() (string, error)
+}
+
+func runShell(shellCmd *Command, opts ShellOptions) error {
+	root := shellCmd.Root()
+	ctx := shellCmd.Context()
+	in, out := shellCmd.InOrStdin(), shellCmd.OutOrStdout()
+
+	prompt := opts.Prompt
+	if prompt == "" {
+		prompt = root.Name() + "> "
+	}
+	historySize := opts.HistorySize
+	if historySize <= 0 {
+		historySize = defaultShellHistorySize
+	}
+	history := loadShellHistory(opts.HistoryFile, historySize)
+
+	var reader shellLineReader
+	if fd, outFd, ok := terminalFds(in, out); ok {
+		reader = &terminalLineReader{
+			fd:    fd,
+			outFd: outFd,
+			editor: &lineEditor{
+				in:      bufio.NewReader(in),
+				out:     out,
+				prompt:  prompt,
+				history: history,
+				complete: func(line string) shellCompletion {
+					return completeShellLine(root, line)
+				},
+			},
+		}
+	} else {
+		reader = &plainLineReader{in: bufio.NewReader(in), out: out, prompt: prompt}
+	}
+
+	for {
+		line, err := reader.readLine()
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(out)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if len(history) == 0 || history[len(history)-1] != line {
+			history = appendShellHistory(history, line, historySize)
+			saveShellHistory(opts.HistoryFile, line)
+		}
+		if terminal, ok := reader.(*terminalLineReader); ok {
+			terminal.editor.history = history
+		}
+
+		if line == "exit" || line == "quit" {
+			return nil
+		}
+		args, err := splitShellWords(line)
+		if err != nil {
+			shellCmd.PrintErrln(shellCmd.ErrPrefix(), err.Error())
+			continue
+		}
+		if cmd, _, err := root.Find(args); err == nil && cmd == shellCmd {
+			shellCmd.PrintErrln(shellCmd.ErrPrefix(), "already in the shell")
+			continue
+		}
+
+		root.ResetState()
+		root.SetArgs(args)
+		// A signal only interrupts the command of the line, not the shell
+		cmd, err := root.executeContextCWithSignals(ctx, SignalOptions{})
+		if err != nil && cmd != nil && (root.SilenceErrors || cmd.SilenceErrors) {
+			// Execute did not print the error, but the user of the shell must see it.
+			cmd.PrintErrln(cmd.ErrPrefix(), err.Error())
+		}
+	}
+}
+
+// plainLineReader reads lines from an input which is not a terminal, without editing.
+type plainLineReader struct {
+	in     *bufio.Reader
+	out    io.Writer
+	prompt string
+}
+
+func (r *plainLineReader) readLine() (string, error) {
+	fmt.Fprint(r.out, r.prompt)
+	line, err := r.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		// Execute the last line, even without a newline
+		return line, nil
+	}
+	return strings.TrimSuffix(line, "\n"), err
+}
+
+// terminalLineReader reads lines from a terminal in raw mode with a lineEditor.
+// The terminal is only in raw mode while reading, so that the commands run normally.
+type terminalLineReader struct {
+	fd     int
+	outFd  int
+	editor *lineEditor
+}
+
+func (r *terminalLineReader) readLine() (string, error) {
+	restore, err := makeRaw(r.fd)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+	restoreOut, err := enableTerminalSequences(r.outFd)
+	if err != nil {
+		return "", err
+	}
+	defer restoreOut()
+	return r.editor.readLine()
+}
+
+// terminalFds returns the file descriptors of in and out if both are terminals.
+func terminalFds(in io.Reader, out io.Writer) (int, int, bool) {
+	inFile, ok := in.(*os.File)
+	if !ok {
+		return 0, 0, false
+	}
+	outFile, ok := out.(*os.File)
+	if !ok {
+		return 0, 0, false
+	}
+	fd, outFd := int(inFile.Fd()), int(outFile.Fd())
+	return fd, outFd, term.IsTerminal(fd) && term.IsTerminal(outFd)
+}
+
+// makeRaw puts the terminal in raw mode and returns the function restoring its previous state.
+func makeRaw(fd int) (func(), error) {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	return func() { _ = term.Restore(fd, state) }, nil
+}
+
+// completeShellLine returns the completion choices for the last word of line, using
+// the same logic as the shell completion.
+func completeShellLine(root *Command, line string) shellCompletion {
+	start := lastWordStart(line)
+	args, err := splitShellWords(line[:start])
+	if err != nil {
+		return shellCompletion{start: start}
+	}
+	toComplete, err := splitShellWords(line[start:])
+	if err != nil {
+		return shellCompletion{start: start}
+	}
+	word := strings.Join(toComplete, "")
+
+	result := root.serveCompletion(compRPCCompleteParams{Args: append(args, word)})
+	comp := shellCompletion{
+		start:      start,
+		activeHelp: result.ActiveHelp,
+		noSpace:    result.Directive.NoSpace,
+	}
+	if result.Directive.Error {
+		return comp
+	}
+
+	switch {
+	case result.Directive.FilterFileExt:
+		var exts []string
+		for _, c := range result.Completions {
+			exts = append(exts, c.Value)
+		}
+		comp.candidates = completeShellFiles(word, false, exts)
+	case result.Directive.FilterDirs:
+		comp.candidates = completeShellFiles(word, true, nil)
+	default:
+		for _, c := range result.Completions {
+			if strings.HasPrefix(c.Value, word) {
+				comp.candidates = append(comp.candidates, c)
+			}
+		}
+		if len(result.Completions) == 0 && !result.Directive.NoFileComp {
+			comp.candidates = completeShellFiles(word, false, nil)
+		}
+	}
+	return comp
+}
+
+// completeShellFiles returns the files and directories starting with prefix.
+// Directories end with a path separator.
+func completeShellFiles(prefix string, dirsOnly bool, exts []string) []completionChoiceJSON {
+	matches, _ := filepath.Glob(globEscape(prefix) + "*")
+	var candidates []completionChoiceJSON
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			candidates = append(candidates, completionChoiceJSON{Value: m + string(filepath.Separator)})
+			continue
+		}
+		if dirsOnly {
+			continue
+		}
+		if len(exts) > 0 && !stringInSlice(strings.TrimPrefix(filepath.Ext(m), "."), exts) {
+			continue
+		}
+		candidates = append(candidates, completionChoiceJSON{Value: m})
+	}
+	return candidates
+}
+
+// globEscape escapes the special characters of filepath.Match in s.
+func globEscape(s string) string {
+	if runtime.GOOS == "windows" {
+		// The backslash is the path separator and cannot escape anything
+		return s
+	}
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[\`, r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// lastWordStart returns the index of the start of the last word of line, which is
+// len(line) if line ends with a space outside of quotes.
+func lastWordStart(line string) int {
+	start := 0
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case unicode.IsSpace(r):
+			start = i + 1
+		}
+	}
+	return start
+}
+
+// splitShellWords splits line into words like a POSIX shell, handling single and double
+// quotes and backslash escapes, but without any expansion.
+func splitShellWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, errors.New("unterminated escape sequence")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// escapeShellWord escapes the characters of word which splitShellWords would interpret.
+func escapeShellWord(word string) string {
+	var sb strings.Builder
+	for _, r := range word {
+		if unicode.IsSpace(r) || strings.ContainsRune(`\'"`, r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// loadShellHistory returns the last lines of the history file.
+func loadShellHistory(path string, size int) []string {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var history []string
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	for _, line := range lines {
+		if line != "" {
+			history = appendShellHistory(history, line, size)
+		}
+	}
+	if len(lines) > size {
+		// Keep the file from growing forever
+		_ = os.WriteFile(path, []byte(strings.Join(history, "\n")+"\n"), 0o600)
+	}
+	return history
+}
+
+// saveShellHistory appends line to the history file.  The history is best-effort,
+// so errors are ignored.
+func saveShellHistory(path, line string) {
+	if path == "" {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = fmt.Fprintln(f, line)
+}
+
+// appendShellHistory adds line to history, skipping consecutive duplicates
+// and dropping the oldest lines beyond size.
+func appendShellHistory(history []string, line string, size int) []string {
+	if len(history) > 0 && history[len(history)-1] == line {
+		return history
+	}
+	history = append(history, line)
+	if len(history) > size {
+		history = history[len(history)-size:]
+	}
+	return history
+}
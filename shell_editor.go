@@ -0,0 +1,283 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Keys handled by the lineEditor.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyBackspace = 8
+	keyTab       = 9
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// shellCompletion holds the completion choices for the word of a line starting at start.
+type shellCompletion struct {
+	start      int
+	candidates []completionChoiceJSON
+	activeHelp []string
+	noSpace    bool
+}
+
+// lineEditor reads a line from a terminal in raw mode, with basic emacs-like
+// editing, the history of the previous lines and TAB completion.
+type lineEditor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	prompt   string
+	history  []string
+	complete func(line string) shellCompletion
+
+	buf []rune
+	pos int
+}
+
+// readLine reads a line.  It returns io.EOF when Ctrl-D is pressed on an empty line.
+func (e *lineEditor) readLine() (string, error) {
+	e.buf, e.pos = nil, 0
+	// The last entry of the history is the line being edited
+	historyIdx := len(e.history)
+	edited := ""
+
+	e.refresh()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case keyEnter, '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(e.buf), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			e.buf, e.pos = nil, 0
+			historyIdx = len(e.history)
+		case keyCtrlD:
+			if len(e.buf) == 0 {
+				return "", io.EOF
+			}
+			e.deleteRune()
+		case keyTab:
+			e.completeWord()
+		case keyCtrlA:
+			e.pos = 0
+		case keyCtrlE:
+			e.pos = len(e.buf)
+		case keyCtrlB:
+			e.moveLeft()
+		case keyCtrlF:
+			e.moveRight()
+		case keyCtrlK:
+			e.buf = e.buf[:e.pos]
+		case keyCtrlU:
+			e.buf = e.buf[e.pos:]
+			e.pos = 0
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case keyCtrlP:
+			historyIdx, edited = e.recall(historyIdx, historyIdx-1, edited)
+		case keyCtrlN:
+			historyIdx, edited = e.recall(historyIdx, historyIdx+1, edited)
+		case keyBackspace, keyDelete:
+			if e.pos > 0 {
+				e.pos--
+				e.deleteRune()
+			}
+		case keyEscape:
+			switch e.readEscapeSequence() {
+			case "[A", "OA":
+				historyIdx, edited = e.recall(historyIdx, historyIdx-1, edited)
+			case "[B", "OB":
+				historyIdx, edited = e.recall(historyIdx, historyIdx+1, edited)
+			case "[C", "OC":
+				e.moveRight()
+			case "[D", "OD":
+				e.moveLeft()
+			case "[H", "OH", "[1~", "[7~":
+				e.pos = 0
+			case "[F", "OF", "[4~", "[8~":
+				e.pos = len(e.buf)
+			case "[3~":
+				e.deleteRune()
+			}
+		default:
+			if unicode.IsPrint(r) {
+				e.insert([]rune{r})
+			}
+		}
+		e.refresh()
+	}
+}
+
+// readEscapeSequence reads the rest of an escape sequence, without the escape character.
+func (e *lineEditor) readEscapeSequence() string {
+	var seq strings.Builder
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return seq.String()
+		}
+		seq.WriteRune(r)
+		// The sequences end with a letter or a tilde, after the [ or O introducing them
+		if seq.Len() > 1 && (unicode.IsLetter(r) || r == '~') {
+			return seq.String()
+		}
+		if seq.Len() == 1 && r != '[' && r != 'O' {
+			return seq.String()
+		}
+	}
+}
+
+// recall replaces the line with the entry of the history at index to, saving the line
+// being edited when leaving it.  It returns the new index and the line being edited.
+func (e *lineEditor) recall(from, to int, edited string) (int, string) {
+	if to < 0 || to > len(e.history) {
+		return from, edited
+	}
+	if from == len(e.history) {
+		edited = string(e.buf)
+	}
+	if to == len(e.history) {
+		e.buf = []rune(edited)
+	} else {
+		e.buf = []rune(e.history[to])
+	}
+	e.pos = len(e.buf)
+	return to, edited
+}
+
+func (e *lineEditor) moveLeft() {
+	if e.pos > 0 {
+		e.pos--
+	}
+}
+
+func (e *lineEditor) moveRight() {
+	if e.pos < len(e.buf) {
+		e.pos++
+	}
+}
+
+// insert inserts runes at the cursor.
+func (e *lineEditor) insert(runes []rune) {
+	buf := make([]rune, 0, len(e.buf)+len(runes))
+	buf = append(buf, e.buf[:e.pos]...)
+	buf = append(buf, runes...)
+	e.buf = append(buf, e.buf[e.pos:]...)
+	e.pos += len(runes)
+}
+
+// deleteRune deletes the rune under the cursor.
+func (e *lineEditor) deleteRune() {
+	if e.pos < len(e.buf) {
+		e.buf = append(e.buf[:e.pos], e.buf[e.pos+1:]...)
+	}
+}
+
+// completeWord completes the word before the cursor.  A single choice replaces the word,
+// several choices extend it to their longest common prefix, and otherwise the choices
+// and the ActiveHelp messages are listed below the line.
+func (e *lineEditor) completeWord() {
+	if e.complete == nil {
+		return
+	}
+	line := string(e.buf[:e.pos])
+	comp := e.complete(line)
+	word := line[comp.start:]
+
+	if len(comp.candidates) == 1 {
+		value := comp.candidates[0].Value
+		replacement := escapeShellWord(value)
+		if !comp.noSpace && !strings.HasSuffix(value, string(os.PathSeparator)) {
+			replacement += " "
+		}
+		e.replaceWord(comp.start, replacement)
+		return
+	}
+
+	if len(comp.candidates) > 1 {
+		prefix := comp.candidates[0].Value
+		for _, c := range comp.candidates[1:] {
+			prefix = commonPrefix(prefix, c.Value)
+		}
+		if unescaped, err := splitShellWords(word); err == nil && len(prefix) > len(strings.Join(unescaped, "")) {
+			e.replaceWord(comp.start, escapeShellWord(prefix))
+			return
+		}
+	}
+
+	if len(comp.candidates) == 0 && len(comp.activeHelp) == 0 {
+		return
+	}
+	fmt.Fprint(e.out, "\r\n")
+	for _, c := range comp.candidates {
+		if c.Description != "" {
+			fmt.Fprintf(e.out, "%s  -- %s\r\n", c.Value, c.Description)
+		} else {
+			fmt.Fprintf(e.out, "%s\r\n", c.Value)
+		}
+	}
+	for _, help := range comp.activeHelp {
+		fmt.Fprintf(e.out, "%s\r\n", help)
+	}
+}
+
+// replaceWord replaces the text between the byte offset start and the cursor.
+func (e *lineEditor) replaceWord(start int, replacement string) {
+	before := []rune(string(e.buf[:e.pos])[:start])
+	after := e.buf[e.pos:]
+	e.buf = append(append(before, []rune(replacement)...), after...)
+	e.pos = len(before) + len([]rune(replacement))
+}
+
+// refresh redraws the line and places the cursor.
+func (e *lineEditor) refresh() {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, string(e.buf))
+	if back := len(e.buf) - e.pos; back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+// commonPrefix returns the longest common prefix of a and b.
+func commonPrefix(a, b string) string {
+	ra, rb := []rune(a), []rune(b)
+	i := 0
+	for i < len(ra) && i < len(rb) && ra[i] == rb[i] {
+		i++
+	}
+	return string(ra[:i])
+}
@@ -0,0 +1,445 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShellExecutesCommands(t *testing.T) {
+	var calls []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	greetCmd := &Command{
+		Use:  "greet",
+		Args: ArbitraryArgs,
+		Run: func(cmd *Command, args []string) {
+			name, _ := cmd.Flags().GetString("name")
+			calls = append(calls, strings.Join(append([]string{name}, args...), " "))
+		},
+	}
+	greetCmd.Flags().String("name", "nobody", "")
+	failCmd := &Command{
+		Use:  "fail",
+		RunE: func(*Command, []string) error { return errors.New("failed") },
+	}
+	rootCmd.AddCommand(greetCmd, failCmd, NewShellCmd(ShellOptions{Prompt: "$ "}))
+	rootCmd.SetIn(strings.NewReader("greet --name bob 'hello world'\n\nfail\ngreet\nexit\ngreet never\n"))
+
+	output, err := executeCommand(rootCmd, "shell")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The flag value of the first line must not leak into the next ones.
+	if expected := []string{"bob hello world", "nobody"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, calls)
+	}
+	checkStringContains(t, output, "$ ")
+	checkStringContains(t, output, "Error: failed")
+}
+
+func TestShellEndOfInput(t *testing.T) {
+	var calls []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(&Command{
+		Use:  "greet",
+		Args: ArbitraryArgs,
+		Run:  func(cmd *Command, args []string) { calls = append(calls, strings.Join(args, " ")) },
+	}, NewShellCmd(ShellOptions{}))
+	rootCmd.SetIn(strings.NewReader("greet last"))
+
+	if _, err := executeCommand(rootCmd, "shell"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"last"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, calls)
+	}
+}
+
+func TestShellErrors(t *testing.T) {
+	var calls []string
+	rootCmd := &Command{Use: "root", Run: emptyRun, SilenceErrors: true}
+	rootCmd.AddCommand(&Command{
+		Use:  "greet",
+		Args: ArbitraryArgs,
+		Run:  func(cmd *Command, args []string) { calls = append(calls, strings.Join(args, " ")) },
+	}, &Command{
+		Use:  "fail",
+		RunE: func(*Command, []string) error { return errors.New("failed") },
+	}, NewShellCmd(ShellOptions{}))
+	rootCmd.SetIn(strings.NewReader("shell\ngreet 'oops\nunknown\nfail\n"))
+
+	output, err := executeCommand(rootCmd, "shell")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "Error: already in the shell")
+	checkStringContains(t, output, "Error: unterminated ' quote")
+	checkStringContains(t, output, `Error: unknown command "unknown" for "root"`)
+	checkStringContains(t, output, "Error: failed")
+	if len(calls) != 0 {
+		t.Errorf("Expected no command to run, got %v", calls)
+	}
+}
+
+func TestShellSignalsAndSliceFlags(t *testing.T) {
+	var registered chan<- os.Signal
+	oldNotify, oldStop := notifySignals, stopSignals
+	notifySignals = func(c chan<- os.Signal, sig ...os.Signal) { registered = c }
+	stopSignals = func(c chan<- os.Signal) {}
+	defer func() { notifySignals, stopSignals = oldNotify, oldStop }()
+
+	var labels [][]string
+	var ctxErrs []error
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	labelCmd := &Command{
+		Use: "label",
+		Run: func(cmd *Command, args []string) {
+			l, _ := cmd.Flags().GetStringSlice("labels")
+			labels = append(labels, l)
+			if wait, _ := cmd.Flags().GetBool("wait"); wait {
+				// Interrupt the command like Ctrl-C does
+				registered <- os.Interrupt
+				<-cmd.Context().Done()
+			}
+			ctxErrs = append(ctxErrs, cmd.Context().Err())
+		},
+	}
+	labelCmd.Flags().StringSlice("labels", nil, "")
+	labelCmd.Flags().Bool("wait", false, "")
+	rootCmd.AddCommand(labelCmd, NewShellCmd(ShellOptions{}))
+	rootCmd.SetIn(strings.NewReader("label --wait --labels a=1\nlabel --labels b=2\n"))
+
+	if _, err := executeCommand(rootCmd, "shell"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The slice of the first line must not leak into the second one, which must
+	// not be interrupted by the signal received by the first one.
+	if expected := [][]string{{"a=1"}, {"b=2"}}; !reflect.DeepEqual(labels, expected) {
+		t.Errorf("Expected labels %v, got %v", expected, labels)
+	}
+	if expected := []error{context.Canceled, nil}; !reflect.DeepEqual(ctxErrs, expected) {
+		t.Errorf("Expected context errors %v, got %v", expected, ctxErrs)
+	}
+}
+
+func TestShellHistoryFile(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), "history")
+	assertNoErr(t, os.WriteFile(historyFile, []byte("one\ntwo\nthree\n"), 0o600))
+
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(NewShellCmd(ShellOptions{HistoryFile: historyFile, HistorySize: 2}))
+	rootCmd.SetIn(strings.NewReader("help\nhelp\nexit\n"))
+
+	output, err := executeCommand(rootCmd, "shell")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "root> ")
+
+	data, err := os.ReadFile(historyFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The file is compacted to the size of the history when loaded.
+	if expected := "two\nthree\nhelp\nexit\n"; string(data) != expected {
+		t.Errorf("Expected history %q, got %q", expected, string(data))
+	}
+	if history := loadShellHistory(historyFile, 10); !reflect.DeepEqual(history, []string{"two", "three", "help", "exit"}) {
+		t.Errorf("Unexpected history %v", history)
+	}
+}
+
+func TestSplitShellWords(t *testing.T) {
+	testcases := []struct {
+		line     string
+		expected []string
+		err      bool
+	}{
+		{line: "", expected: nil},
+		{line: "  a  b ", expected: []string{"a", "b"}},
+		{line: `a "b c" 'd e'`, expected: []string{"a", "b c", "d e"}},
+		{line: `a\ b "c\"d" 'e\f'`, expected: []string{"a b", `c"d`, `e\f`}},
+		{line: `--flag="" x`, expected: []string{"--flag=", "x"}},
+		{line: `""`, expected: []string{""}},
+		{line: `"a`, err: true},
+		{line: `a\`, err: true},
+	}
+	for _, tc := range testcases {
+		words, err := splitShellWords(tc.line)
+		if (err != nil) != tc.err {
+			t.Errorf("%q: expected error %v, got %v", tc.line, tc.err, err)
+			continue
+		}
+		if !reflect.DeepEqual(words, tc.expected) {
+			t.Errorf("%q: expected %q, got %q", tc.line, tc.expected, words)
+		}
+		if len(words) == 1 && words[0] != "" {
+			if escaped, _ := splitShellWords(escapeShellWord(words[0])); !reflect.DeepEqual(escaped, words) {
+				t.Errorf("%q: expected escapeShellWord to round-trip, got %q", tc.line, escaped)
+			}
+		}
+	}
+}
+
+func TestLastWordStart(t *testing.T) {
+	testcases := map[string]int{
+		"":             0,
+		"greet":        0,
+		"greet ":       6,
+		"greet --na":   6,
+		`greet "a b`:   6,
+		`greet a\ b`:   6,
+		`greet 'a b' `: 12,
+	}
+	for line, expected := range testcases {
+		if start := lastWordStart(line); start != expected {
+			t.Errorf("%q: expected %d, got %d", line, expected, start)
+		}
+	}
+}
+
+func TestCompleteShellLine(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{
+		Use:       "child",
+		Short:     "A child",
+		ValidArgs: []string{"red", "green", "blue"},
+		Run:       emptyRun,
+	}
+	childCmd.Flags().String("name", "", "")
+	activeHelpCmd := &Command{
+		Use: "helpful",
+		ValidArgsFunction: func(cmd *Command, args []string, toComplete string) ([]string, ShellCompDirective) {
+			return AppendActiveHelp(nil, "some help"), ShellCompDirectiveNoFileComp
+		},
+		Run: emptyRun,
+	}
+	rootCmd.AddCommand(childCmd, activeHelpCmd)
+
+	comp := completeShellLine(rootCmd, "chi")
+	if comp.start != 0 || len(comp.candidates) != 1 || comp.candidates[0] != (completionChoiceJSON{Value: "child", Description: "A child"}) {
+		t.Errorf("Unexpected completion of the command: %+v", comp)
+	}
+
+	comp = completeShellLine(rootCmd, "child gr")
+	if comp.start != 6 || len(comp.candidates) != 1 || comp.candidates[0].Value != "green" {
+		t.Errorf("Unexpected completion of the argument: %+v", comp)
+	}
+
+	comp = completeShellLine(rootCmd, "child --na")
+	if len(comp.candidates) != 1 || comp.candidates[0].Value != "--name" {
+		t.Errorf("Unexpected completion of the flag: %+v", comp)
+	}
+
+	comp = completeShellLine(rootCmd, "helpful ")
+	if comp.start != 8 || len(comp.candidates) != 0 || !reflect.DeepEqual(comp.activeHelp, []string{"some help"}) {
+		t.Errorf("Unexpected completion with ActiveHelp: %+v", comp)
+	}
+}
+
+func TestCompleteShellLineThenExecute(t *testing.T) {
+	var calls int
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	deployCmd := &Command{Use: "deploy", Run: func(*Command, []string) { calls++ }}
+	deployCmd.Flags().String("a", "", "")
+	deployCmd.Flags().String("b", "", "")
+	deployCmd.MarkFlagsRequiredTogether("a", "b")
+	rootCmd.AddCommand(deployCmd, NewShellCmd(ShellOptions{}))
+
+	// Pressing TAB makes --b required for the completion only
+	comp := completeShellLine(rootCmd, "deploy --a x --")
+	if len(comp.candidates) != 1 || comp.candidates[0].Value != "--b" {
+		t.Errorf("Unexpected completion of the flag group: %+v", comp)
+	}
+
+	rootCmd.SetIn(strings.NewReader("deploy\n"))
+	output, err := executeCommand(rootCmd, "shell")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the command to run after the completion, got %d calls and output %q", calls, output)
+	}
+}
+
+func TestCompleteShellFiles(t *testing.T) {
+	dir := t.TempDir()
+	assertNoErr(t, os.WriteFile(filepath.Join(dir, "file.txt"), nil, 0o600))
+	assertNoErr(t, os.WriteFile(filepath.Join(dir, "file.json"), nil, 0o600))
+	assertNoErr(t, os.Mkdir(filepath.Join(dir, "folder"), 0o700))
+	prefix := dir + string(filepath.Separator)
+
+	values := func(candidates []completionChoiceJSON) []string {
+		var values []string
+		for _, c := range candidates {
+			values = append(values, strings.TrimPrefix(c.Value, prefix))
+		}
+		return values
+	}
+
+	if got := values(completeShellFiles(prefix+"f", false, nil)); !reflect.DeepEqual(got, []string{"file.json", "file.txt", "folder" + string(filepath.Separator)}) {
+		t.Errorf("Unexpected files %v", got)
+	}
+	if got := values(completeShellFiles(prefix+"f", false, []string{"txt"})); !reflect.DeepEqual(got, []string{"file.txt", "folder" + string(filepath.Separator)}) {
+		t.Errorf("Unexpected files filtered by extension %v", got)
+	}
+	if got := values(completeShellFiles(prefix, true, nil)); !reflect.DeepEqual(got, []string{"folder" + string(filepath.Separator)}) {
+		t.Errorf("Unexpected directories %v", got)
+	}
+}
+
+func newTestLineEditor(keys string, history []string, comp shellCompletion) (*lineEditor, *bytes.Buffer) {
+	out := new(bytes.Buffer)
+	return &lineEditor{
+		in:       bufio.NewReader(strings.NewReader(keys)),
+		out:      out,
+		prompt:   "> ",
+		history:  history,
+		complete: func(string) shellCompletion { return comp },
+	}, out
+}
+
+func TestLineEditorEditing(t *testing.T) {
+	testcases := []struct {
+		desc     string
+		keys     string
+		expected string
+	}{
+		{desc: "plain", keys: "hello\r", expected: "hello"},
+		{desc: "backspace", keys: "helxx\x7f\x7flo\r", expected: "hello"},
+		{desc: "arrows", keys: "hllo\x1b[D\x1b[D\x1b[De\x1b[C\x1b[C\x1b[C!\r", expected: "hello!"},
+		{desc: "home and end", keys: "ello\x01h\x05!\r", expected: "hello!"},
+		{desc: "delete", keys: "hxello\x01\x1b[C\x1b[3~\r", expected: "hello"},
+		{desc: "kill", keys: "hello world\x01\x06\x06\x06\x06\x06\x0b\r", expected: "hello"},
+		{desc: "kill before", keys: "hello world\x1b[D\x1b[D\x1b[D\x1b[D\x1b[D\x15\r", expected: "world"},
+		{desc: "ctrl-c", keys: "oops\x03hello\r", expected: "hello"},
+		{desc: "ctrl-d deletes", keys: "hxello\x01\x06\x04\r", expected: "hello"},
+		{desc: "unicode", keys: "héllo\x7f\r", expected: "héll"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			editor, _ := newTestLineEditor(tc.keys, nil, shellCompletion{})
+			line, err := editor.readLine()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if line != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, line)
+			}
+		})
+	}
+}
+
+func TestLineEditorEOF(t *testing.T) {
+	editor, _ := newTestLineEditor("\x04", nil, shellCompletion{})
+	if _, err := editor.readLine(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestLineEditorHistory(t *testing.T) {
+	history := []string{"first", "second"}
+	testcases := []struct {
+		keys     string
+		expected string
+	}{
+		{keys: "\x1b[A\r", expected: "second"},
+		{keys: "\x1b[A\x1b[A\x1b[A\r", expected: "first"},
+		{keys: "\x10\x10\x0e\r", expected: "second"},
+		{keys: "new\x1b[A\x1b[B\r", expected: "new"},
+		{keys: "\x1b[A!\r", expected: "second!"},
+	}
+	for _, tc := range testcases {
+		editor, _ := newTestLineEditor(tc.keys, history, shellCompletion{})
+		line, err := editor.readLine()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if line != tc.expected {
+			t.Errorf("%q: expected %q, got %q", tc.keys, tc.expected, line)
+		}
+	}
+}
+
+func TestLineEditorCompletion(t *testing.T) {
+	testcases := []struct {
+		desc     string
+		keys     string
+		comp     shellCompletion
+		expected string
+		listed   []string
+	}{
+		{
+			desc:     "single choice",
+			keys:     "child gr\t\r",
+			comp:     shellCompletion{start: 6, candidates: []completionChoiceJSON{{Value: "green"}}},
+			expected: "child green ",
+		},
+		{
+			desc:     "no space",
+			keys:     "child --name=\t\r",
+			comp:     shellCompletion{start: 6, candidates: []completionChoiceJSON{{Value: "--name=bob"}}, noSpace: true},
+			expected: "child --name=bob",
+		},
+		{
+			desc:     "escaped choice",
+			keys:     "child \t\r",
+			comp:     shellCompletion{start: 6, candidates: []completionChoiceJSON{{Value: "a b"}}},
+			expected: `child a\ b `,
+		},
+		{
+			desc:     "common prefix",
+			keys:     "child b\t\r",
+			comp:     shellCompletion{start: 6, candidates: []completionChoiceJSON{{Value: "blue"}, {Value: "black"}}},
+			expected: "child bl",
+		},
+		{
+			desc: "list",
+			keys: "child bl\t\r",
+			comp: shellCompletion{
+				start:      6,
+				candidates: []completionChoiceJSON{{Value: "blue", Description: "The sky"}, {Value: "black"}},
+				activeHelp: []string{"pick a color"},
+			},
+			expected: "child bl",
+			listed:   []string{"blue  -- The sky\r\n", "black\r\n", "pick a color\r\n"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.desc, func(t *testing.T) {
+			editor, out := newTestLineEditor(tc.keys, nil, tc.comp)
+			line, err := editor.readLine()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if line != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, line)
+			}
+			for _, listed := range tc.listed {
+				checkStringContains(t, out.String(), listed)
+			}
+		})
+	}
+}
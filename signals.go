@@ -0,0 +1,162 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// SignalOptions configures how ExecuteWithSignals handles OS signals.
+type SignalOptions struct {
+	// Signals are the signals which interrupt the command.
+	// They default to os.Interrupt and syscall.SIGTERM, or only os.Interrupt on Plan 9.
+	Signals []os.Signal
+
+	// GracePeriod is how long the command has to return once its context has been
+	// cancelled by a first signal.  When it expires, the program exits.
+	// Zero means the command can take as long as it needs.
+	GracePeriod time.Duration
+}
+
+// notifySignals and stopSignals are variables for testing purposes.
+var (
+	notifySignals = signal.Notify
+	stopSignals   = signal.Stop
+)
+
+// signalStateKey is the key of the signalState in the context of the executed command.
+type signalStateKey struct{}
+
+// signalState records the signal which interrupted the execution, if any.
+type signalState struct {
+	mu  sync.Mutex
+	sig os.Signal
+}
+
+func (s *signalState) set(sig os.Signal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sig = sig
+}
+
+func (s *signalState) get() os.Signal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sig
+}
+
+// ExecuteWithSignals is the same as Execute(), but cancels the context of the command
+// when the program receives one of the signals of opts.  Retrieve the context by calling
+// cmd.Context() inside your *Run lifecycle functions to stop gracefully.
+//
+// Once interrupted, the command has the grace period of opts to return.  The post-run
+// hooks are still run, even if RunE returns an error, and so are the finalizers registered
+// with OnFinalize.  If the grace period expires or if a second signal is received,
+// the program exits immediately with the status 128+<signal number>, see SetExitFunc.
+func (c *Command) ExecuteWithSignals(opts SignalOptions) error {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return c.ExecuteContextWithSignals(ctx, opts)
+}
+
+// ExecuteContextWithSignals is the same as ExecuteWithSignals(), but derives the context
+// of the command from ctx.
+func (c *Command) ExecuteContextWithSignals(ctx context.Context, opts SignalOptions) error {
+	_, err := c.executeContextCWithSignals(ctx, opts)
+	return err
+}
+
+// executeContextCWithSignals is the same as ExecuteContextWithSignals(), but also returns
+// the executed command, like ExecuteC().
+func (c *Command) executeContextCWithSignals(ctx context.Context, opts SignalOptions) (*Command, error) {
+	signals := opts.Signals
+	if len(signals) == 0 {
+		signals = defaultSignals()
+	}
+
+	state := &signalState{}
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, signalStateKey{}, state))
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 2)
+	notifySignals(sigCh, signals...)
+	defer stopSignals(sigCh)
+
+	done := make(chan struct{})
+	defer close(done)
+	go handleSignals(sigCh, done, state, cancel, opts.GracePeriod, c.ExitFunc())
+
+	// The context is cancelled when the execution is over: do not leave it
+	// behind for the next execution
+	prev := c.ctx
+	defer func() {
+		c.forgetContext(ctx)
+		c.ctx = prev
+	}()
+	return c.ExecuteContextC(ctx)
+}
+
+// forgetContext clears the context of c and its subcommands if it is ctx.
+func (c *Command) forgetContext(ctx context.Context) {
+	if c.ctx == ctx {
+		c.ctx = nil
+	}
+	for _, sub := range c.commands {
+		sub.forgetContext(ctx)
+	}
+}
+
+// handleSignals cancels the execution on the first signal and exits on the second one,
+// or when the grace period expires.
+func handleSignals(sigCh <-chan os.Signal, done <-chan struct{}, state *signalState, cancel context.CancelFunc, gracePeriod time.Duration, exit func(int)) {
+	var sig os.Signal
+	select {
+	case sig = <-sigCh:
+	case <-done:
+		return
+	}
+	state.set(sig)
+	cancel()
+
+	var timeout <-chan time.Time
+	if gracePeriod > 0 {
+		timer := time.NewTimer(gracePeriod)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-done:
+		return
+	case sig = <-sigCh:
+	case <-timeout:
+	}
+	exit(signalExitCode(sig))
+}
+
+// interruptedBySignal returns true if ctx is the context of an execution
+// which has been interrupted by a signal.
+func interruptedBySignal(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	state, ok := ctx.Value(signalStateKey{}).(*signalState)
+	return ok && state.get() != nil
+}
@@ -0,0 +1,28 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import "os"
+
+// defaultSignals returns the signals which interrupt a command by default.
+func defaultSignals() []os.Signal {
+	return []os.Signal{os.Interrupt}
+}
+
+// signalExitCode returns the exit status of a program terminated by sig.
+// Notes have no number on Plan 9.
+func signalExitCode(sig os.Signal) int {
+	return 1
+}
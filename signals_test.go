@@ -0,0 +1,211 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !plan9
+// +build !plan9
+
+package cobra
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeSignals replaces the signal handling functions and returns a channel
+// which receives the channel registered by ExecuteWithSignals, and a channel
+// which receives the exit codes.
+func fakeSignals(t *testing.T) (<-chan chan<- os.Signal, <-chan int) {
+	registered := make(chan chan<- os.Signal, 1)
+	exits := make(chan int, 1)
+
+	oldNotify, oldStop, oldExit := notifySignals, stopSignals, exitFunc
+	notifySignals = func(c chan<- os.Signal, sig ...os.Signal) { registered <- c }
+	stopSignals = func(c chan<- os.Signal) {}
+	exitFunc = func(code int) { exits <- code }
+	t.Cleanup(func() {
+		notifySignals, stopSignals, exitFunc = oldNotify, oldStop, oldExit
+	})
+
+	return registered, exits
+}
+
+func TestExecuteWithSignalsNoSignal(t *testing.T) {
+	var notified []os.Signal
+	oldNotify, oldStop := notifySignals, stopSignals
+	notifySignals = func(c chan<- os.Signal, sig ...os.Signal) { notified = sig }
+	stopSignals = func(c chan<- os.Signal) {}
+	defer func() { notifySignals, stopSignals = oldNotify, oldStop }()
+
+	var ctxErr error
+	rootCmd := &Command{
+		Use: "root",
+		RunE: func(cmd *Command, args []string) error {
+			ctxErr = cmd.Context().Err()
+			return nil
+		},
+	}
+	rootCmd.SetArgs([]string{})
+
+	if err := rootCmd.ExecuteWithSignals(SignalOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ctxErr != nil {
+		t.Errorf("Expected the context not to be cancelled, got %v", ctxErr)
+	}
+	expected := []os.Signal{os.Interrupt, syscall.SIGTERM}
+	if !reflect.DeepEqual(notified, expected) {
+		t.Errorf("Expected signals %v, got %v", expected, notified)
+	}
+	if rootCmd.Context() != nil {
+		t.Error("Expected the context of the execution not to be left behind")
+	}
+}
+
+func TestExecuteWithSignalsTwice(t *testing.T) {
+	oldNotify, oldStop := notifySignals, stopSignals
+	notifySignals = func(c chan<- os.Signal, sig ...os.Signal) {}
+	stopSignals = func(c chan<- os.Signal) {}
+	defer func() { notifySignals, stopSignals = oldNotify, oldStop }()
+
+	var ctxErrs []error
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	childCmd := &Command{
+		Use: "child",
+		RunE: func(cmd *Command, args []string) error {
+			ctxErrs = append(ctxErrs, cmd.Context().Err())
+			return nil
+		},
+	}
+	rootCmd.AddCommand(childCmd)
+
+	for i := 0; i < 2; i++ {
+		rootCmd.SetArgs([]string{"child"})
+		if err := rootCmd.ExecuteWithSignals(SignalOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if expected := []error{nil, nil}; !reflect.DeepEqual(ctxErrs, expected) {
+		t.Errorf("Expected the contexts not to be cancelled, got %v", ctxErrs)
+	}
+}
+
+func TestExecuteWithSignalsGracefulShutdown(t *testing.T) {
+	registered, exits := fakeSignals(t)
+
+	var postRun, persistentPostRun, finalized bool
+	OnFinalize(func() { finalized = true })
+	defer func() { finalizers = nil }()
+
+	interrupted := errors.New("interrupted")
+	rootCmd := &Command{
+		Use:               "root",
+		PersistentPostRun: func(*Command, []string) { persistentPostRun = true },
+	}
+	childCmd := &Command{
+		Use: "child",
+		RunE: func(cmd *Command, args []string) error {
+			(<-registered) <- syscall.SIGTERM
+			<-cmd.Context().Done()
+			return interrupted
+		},
+		PostRun: func(*Command, []string) { postRun = true },
+	}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.SetArgs([]string{"child"})
+
+	err := rootCmd.ExecuteContextWithSignals(context.Background(), SignalOptions{GracePeriod: time.Hour})
+	if err != interrupted {
+		t.Fatalf("Expected error %v, got %v", interrupted, err)
+	}
+	if !postRun || !persistentPostRun {
+		t.Error("Expected the post-run hooks to be run after a signal")
+	}
+	if !finalized {
+		t.Error("Expected the finalizers to be run after a signal")
+	}
+	select {
+	case code := <-exits:
+		t.Errorf("Expected no forced exit, got exit code %d", code)
+	default:
+	}
+}
+
+func TestExecuteWithSignalsErrorWithoutSignal(t *testing.T) {
+	_, _ = fakeSignals(t)
+
+	var postRun bool
+	rootCmd := &Command{
+		Use:     "root",
+		RunE:    func(*Command, []string) error { return errors.New("failed") },
+		PostRun: func(*Command, []string) { postRun = true },
+	}
+	rootCmd.SetArgs([]string{})
+
+	if err := rootCmd.ExecuteWithSignals(SignalOptions{}); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if postRun {
+		t.Error("Expected the post-run hooks not to be run when RunE fails without a signal")
+	}
+}
+
+func TestExecuteWithSignalsSecondSignal(t *testing.T) {
+	registered, exits := fakeSignals(t)
+
+	var code int
+	rootCmd := &Command{
+		Use: "root",
+		Run: func(cmd *Command, args []string) {
+			sigCh := <-registered
+			sigCh <- os.Interrupt
+			<-cmd.Context().Done()
+			sigCh <- os.Interrupt
+			code = <-exits
+		},
+	}
+	rootCmd.SetArgs([]string{})
+
+	if err := rootCmd.ExecuteWithSignals(SignalOptions{Signals: []os.Signal{os.Interrupt}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := 128 + int(syscall.SIGINT); code != expected {
+		t.Errorf("Expected exit code %d, got %d", expected, code)
+	}
+}
+
+func TestExecuteWithSignalsGracePeriod(t *testing.T) {
+	registered, exits := fakeSignals(t)
+
+	var code int
+	rootCmd := &Command{
+		Use: "root",
+		Run: func(cmd *Command, args []string) {
+			(<-registered) <- syscall.SIGTERM
+			code = <-exits
+		},
+	}
+	rootCmd.SetArgs([]string{})
+
+	if err := rootCmd.ExecuteWithSignals(SignalOptions{GracePeriod: time.Millisecond}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := 128 + int(syscall.SIGTERM); code != expected {
+		t.Errorf("Expected exit code %d, got %d", expected, code)
+	}
+}
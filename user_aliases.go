@@ -0,0 +1,223 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	// userAliasAnnotation holds the expansion of the commands added for user aliases.
+	userAliasAnnotation = "cobra_annotation_user_alias"
+
+	userAliasGroupID    = "aliases"
+	userAliasGroupTitle = "User Aliases:"
+)
+
+// AddUserAlias defines name as an alias for the arguments of expansion, like the aliases of git:
+// with AddUserAlias("deploy-prod", "deploy --env prod --confirm"), "myapp deploy-prod v2"
+// runs "myapp deploy --env prod --confirm v2".  The arguments of expansion are split like
+// a POSIX shell does, with quotes and backslashes.
+//
+// An expansion starting with "!" is a shell alias: the rest of the expansion is run by the shell
+// (sh, or cmd on Windows) with the arguments following the alias appended.
+//
+// Aliases are listed in the help of the command under "User Aliases:".  They can expand to other
+// aliases, but a loop is an error.  The subcommands of c take precedence over the aliases.
+// User aliases are only used when defined on the root command.
+func (c *Command) AddUserAlias(name, expansion string) error {
+	if name == "" || strings.HasPrefix(name, "-") || strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("invalid alias name %q", name)
+	}
+	if !strings.HasPrefix(expansion, "!") {
+		args, err := splitShellWords(expansion)
+		if err != nil {
+			return fmt.Errorf("invalid expansion of alias %q: %v", name, err)
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("alias %q has an empty expansion", name)
+		}
+	} else if strings.TrimSpace(expansion[1:]) == "" {
+		return fmt.Errorf("alias %q has an empty expansion", name)
+	}
+
+	if c.userAliases == nil {
+		c.userAliases = map[string]string{}
+	}
+	c.userAliases[name] = expansion
+	// Replace the command of a previous definition
+	for _, cmd := range c.commands {
+		if _, ok := cmd.Annotations[userAliasAnnotation]; ok && cmd.Name() == name {
+			c.RemoveCommand(cmd)
+		}
+	}
+	return nil
+}
+
+// LoadUserAliases adds the aliases defined in the YAML or JSON file at path, which maps
+// the name of each alias to its expansion, see AddUserAlias.  For example:
+//
+//	deploy-prod: deploy --env prod --confirm
+//	st: "!git status"
+//
+// A file which does not exist defines no alias.
+func (c *Command) LoadUserAliases(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	aliases := map[string]string{}
+	if err := yaml.Unmarshal(data, &aliases); err != nil {
+		return fmt.Errorf("invalid alias file %s: %v", path, err)
+	}
+	for name, expansion := range aliases {
+		if err := c.AddUserAlias(name, expansion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initUserAliasCmds adds a subcommand for each user alias, so that the aliases are
+// shown in the help and completed, and the shell aliases can be executed.
+func (c *Command) initUserAliasCmds() {
+	names := make([]string, 0, len(c.userAliases))
+	for name := range c.userAliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if c.hasSubCommand(name) {
+			continue
+		}
+		if !c.ContainsGroup(userAliasGroupID) {
+			c.AddGroup(&Group{ID: userAliasGroupID, Title: userAliasGroupTitle})
+		}
+		c.AddCommand(newUserAliasCmd(name, c.userAliases[name]))
+	}
+}
+
+// newUserAliasCmd returns the command added for a user alias.
+func newUserAliasCmd(name, expansion string) *Command {
+	return &Command{
+		Use:                name,
+		Short:              "Alias for " + expansion,
+		GroupID:            userAliasGroupID,
+		Annotations:        map[string]string{userAliasAnnotation: expansion},
+		DisableFlagParsing: true,
+		SilenceErrors:      strings.HasPrefix(expansion, "!"),
+		SilenceUsage:       true,
+		RunE: func(cmd *Command, args []string) error {
+			if !strings.HasPrefix(expansion, "!") {
+				// The aliases are expanded before looking for the command to execute
+				return fmt.Errorf("alias %q could not be expanded", name)
+			}
+			return runShellAlias(cmd, expansion[1:], args)
+		},
+		autoAdded: true,
+	}
+}
+
+// runShellAlias runs script with the shell, appending args.
+func runShellAlias(cmd *Command, script string, args []string) error {
+	return runExecCmd(cmd, shellAliasCmd(pluginContext(cmd), script, cmd.Name(), args))
+}
+
+// quoteCmdArg quotes arg for the command line of cmd.exe: arg is quoted for the
+// program receiving it, then the special characters of cmd.exe are escaped with '^'.
+func quoteCmdArg(arg string) string {
+	quoted := arg
+	if arg == "" || strings.ContainsAny(arg, " \t\n\v\"") {
+		var sb strings.Builder
+		sb.WriteByte('"')
+		backslashes := 0
+		for _, r := range arg {
+			switch r {
+			case '\\':
+				backslashes++
+				continue
+			case '"':
+				// The backslashes before a quote and the quote itself are escaped
+				sb.WriteString(strings.Repeat(`\`, 2*backslashes+1))
+			default:
+				sb.WriteString(strings.Repeat(`\`, backslashes))
+			}
+			sb.WriteRune(r)
+			backslashes = 0
+		}
+		sb.WriteString(strings.Repeat(`\`, 2*backslashes))
+		sb.WriteByte('"')
+		quoted = sb.String()
+	}
+
+	var sb strings.Builder
+	for _, r := range quoted {
+		if strings.ContainsRune(`()%!^"<>&|`, r) {
+			sb.WriteByte('^')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// expandUserAliases replaces the user alias found in args by its expansion, until the
+// arguments do not start with an alias anymore.  Shell aliases are not expanded.
+func (c *Command) expandUserAliases(args []string) ([]string, error) {
+	var expanded []string
+	for {
+		argsWOflags := stripFlags(args, c)
+		if len(argsWOflags) == 0 {
+			return args, nil
+		}
+		name := argsWOflags[0]
+		cmd := c.findNext(name)
+		if cmd == nil {
+			return args, nil
+		}
+		expansion, ok := cmd.Annotations[userAliasAnnotation]
+		if !ok || strings.HasPrefix(expansion, "!") {
+			return args, nil
+		}
+
+		for _, previous := range expanded {
+			if previous == cmd.Name() {
+				return nil, fmt.Errorf("alias loop: %s -> %s", strings.Join(expanded, " -> "), cmd.Name())
+			}
+		}
+		expanded = append(expanded, cmd.Name())
+
+		expansionArgs, _ := splitShellWords(expansion)
+		rest := c.argsMinusFirstX(args, name)
+		pos := 0
+		for pos < len(rest) && args[pos] == rest[pos] {
+			pos++
+		}
+		newArgs := make([]string, 0, len(args)+len(expansionArgs)-1)
+		newArgs = append(newArgs, args[:pos]...)
+		newArgs = append(newArgs, expansionArgs...)
+		args = append(newArgs, args[pos+1:]...)
+	}
+}
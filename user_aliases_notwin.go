@@ -0,0 +1,28 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package cobra
+
+import (
+	"context"
+	"os/exec"
+)
+
+// shellAliasCmd returns the command running script with sh, with args appended.
+func shellAliasCmd(ctx context.Context, script, name string, args []string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", append([]string{"-c", script + ` "$@"`, name}, args...)...)
+}
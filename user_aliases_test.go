@@ -0,0 +1,227 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestUserAliasExpansion(t *testing.T) {
+	testcases := []struct {
+		args     []string
+		expected []string
+	}{
+		{args: []string{"deploy-prod", "v2"}, expected: []string{"prod v2", "yes"}},
+		{args: []string{"ship"}, expected: []string{"prod v 3", "yes"}},
+		// The flags before the alias are kept
+		{args: []string{"--verbose", "deploy-prod", "v1"}, expected: []string{"prod v1", "yes", "verbose"}},
+	}
+	for _, tc := range testcases {
+		var calls []string
+		rootCmd := &Command{Use: "root", Run: emptyRun}
+		rootCmd.PersistentFlags().Bool("verbose", false, "")
+		deployCmd := &Command{
+			Use: "deploy",
+			Run: func(cmd *Command, args []string) {
+				env, _ := cmd.Flags().GetString("env")
+				calls = append(calls, strings.Join(append([]string{env}, args...), " "))
+				if yes, _ := cmd.Flags().GetBool("yes"); yes {
+					calls = append(calls, "yes")
+				}
+				if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+					calls = append(calls, "verbose")
+				}
+			},
+		}
+		deployCmd.Flags().String("env", "dev", "")
+		deployCmd.Flags().Bool("yes", false, "")
+		rootCmd.AddCommand(deployCmd)
+		assertNoErr(t, rootCmd.AddUserAlias("deploy-prod", "deploy --env prod --yes"))
+		assertNoErr(t, rootCmd.AddUserAlias("ship", "deploy-prod 'v 3'"))
+
+		if _, err := executeCommand(rootCmd, tc.args...); err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.args, err)
+		}
+		if !reflect.DeepEqual(calls, tc.expected) {
+			t.Errorf("%v: expected calls %v, got %v", tc.args, tc.expected, calls)
+		}
+	}
+}
+
+func TestUserAliasPrecedence(t *testing.T) {
+	var calls []string
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	deployCmd := &Command{
+		Use: "deploy",
+		Run: func(cmd *Command, args []string) {
+			env, _ := cmd.Flags().GetString("env")
+			calls = append(calls, strings.Join(append([]string{env}, args...), " "))
+		},
+	}
+	deployCmd.Flags().String("env", "dev", "")
+	rootCmd.AddCommand(deployCmd)
+	assertNoErr(t, rootCmd.AddUserAlias("deploy", "deploy --env prod"))
+
+	if _, err := executeCommand(rootCmd, "deploy", "v1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"dev v1"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected the subcommand to take precedence, got %v", calls)
+	}
+}
+
+func TestUserAliasLoop(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.PersistentFlags().Bool("verbose", false, "")
+	assertNoErr(t, rootCmd.AddUserAlias("a", "b --verbose"))
+	assertNoErr(t, rootCmd.AddUserAlias("b", "c"))
+	assertNoErr(t, rootCmd.AddUserAlias("c", "a"))
+
+	output, err := executeCommand(rootCmd, "a")
+	if err == nil || err.Error() != "alias loop: a -> b -> c -> a" {
+		t.Errorf("Expected the loop to be detected, got %v", err)
+	}
+	checkStringContains(t, output, "Error: alias loop: a -> b -> c -> a")
+}
+
+func TestUserAliasHelp(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.AddCommand(&Command{Use: "deploy", Short: "Deploy a release", Run: emptyRun})
+	assertNoErr(t, rootCmd.AddUserAlias("deploy-prod", "deploy --env prod"))
+	assertNoErr(t, rootCmd.AddUserAlias("deploy-prod", "deploy --env prod --yes"))
+
+	output, err := executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "User Aliases:\n  deploy-prod Alias for deploy --env prod --yes\n")
+}
+
+func TestUserAliasCompletion(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	deployCmd := &Command{Use: "deploy", ValidArgs: []string{"v1", "v2"}, Run: emptyRun}
+	deployCmd.Flags().String("env", "dev", "")
+	deployCmd.Flags().Bool("yes", false, "")
+	rootCmd.AddCommand(deployCmd)
+	assertNoErr(t, rootCmd.AddUserAlias("deploy-prod", "deploy --env prod"))
+
+	output, err := executeCommand(rootCmd, ShellCompNoDescRequestCmd, "deploy-prod", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := strings.Join([]string{"v1", "v2", ":4", "Completion ended with directive: ShellCompDirectiveNoFileComp", ""}, "\n")
+	if output != expected {
+		t.Errorf("expected: %q, got: %q", expected, output)
+	}
+
+	output, err = executeCommand(rootCmd, ShellCompNoDescRequestCmd, "deploy-prod", "--y")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "--yes\n")
+
+	output, err = executeCommand(rootCmd, ShellCompNoDescRequestCmd, "dep")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "deploy\ndeploy-prod\n")
+}
+
+func TestUserShellAlias(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test uses sh")
+	}
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	assertNoErr(t, rootCmd.AddUserAlias("hello", "!echo hello; exit 4"))
+	assertNoErr(t, rootCmd.AddUserAlias("greet", "!echo hi"))
+	assertNoErr(t, rootCmd.AddUserAlias("hi", "greet"))
+
+	output, err := executeCommand(rootCmd, "hi", "you", "and me")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output != "hi you and me\n" {
+		t.Errorf("Expected the shell alias to receive the arguments, got %q", output)
+	}
+
+	output, err = executeCommand(rootCmd, "hello")
+	if code := ExitCode(err); code != 4 {
+		t.Errorf("Expected the exit code of the shell, got %d (%v)", code, err)
+	}
+	if output != "hello\n" {
+		t.Errorf("Expected only the output of the shell, got %q", output)
+	}
+}
+
+func TestQuoteCmdArg(t *testing.T) {
+	testcases := []struct {
+		arg      string
+		expected string
+	}{
+		{arg: "v1", expected: "v1"},
+		{arg: "", expected: `^"^"`},
+		{arg: "and me", expected: `^"and me^"`},
+		{arg: `say "hi"`, expected: `^"say \^"hi\^"^"`},
+		{arg: `C:\dir\`, expected: `C:\dir\`},
+		{arg: `C:\my dir\`, expected: `^"C:\my dir\\^"`},
+		{arg: "a&b|c", expected: "a^&b^|c"},
+		{arg: "%PATH%", expected: "^%PATH^%"},
+	}
+	for _, tc := range testcases {
+		if got := quoteCmdArg(tc.arg); got != tc.expected {
+			t.Errorf("%q: expected %s, got %s", tc.arg, tc.expected, got)
+		}
+	}
+}
+
+func TestLoadUserAliases(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	dir := t.TempDir()
+
+	assertNoErr(t, rootCmd.LoadUserAliases(filepath.Join(dir, "missing.yaml")))
+
+	path := filepath.Join(dir, "aliases.yaml")
+	assertNoErr(t, os.WriteFile(path, []byte("deploy-prod: deploy --env prod\nst: \"!git status\"\n"), 0o600))
+	assertNoErr(t, rootCmd.LoadUserAliases(path))
+	if expected := map[string]string{"deploy-prod": "deploy --env prod", "st": "!git status"}; !reflect.DeepEqual(rootCmd.userAliases, expected) {
+		t.Errorf("Expected aliases %v, got %v", expected, rootCmd.userAliases)
+	}
+
+	assertNoErr(t, os.WriteFile(path, []byte("bad: \"deploy 'v1\"\n"), 0o600))
+	if err := rootCmd.LoadUserAliases(path); err == nil {
+		t.Error("Expected an error for an invalid expansion")
+	}
+}
+
+func TestAddUserAliasErrors(t *testing.T) {
+	rootCmd := &Command{Use: "root"}
+	for _, tc := range []struct{ name, expansion string }{
+		{"", "deploy"},
+		{"-d", "deploy"},
+		{"my alias", "deploy"},
+		{"empty", ""},
+		{"empty-shell", "! "},
+		{"quote", `deploy "v1`},
+	} {
+		if err := rootCmd.AddUserAlias(tc.name, tc.expansion); err == nil {
+			t.Errorf("Expected an error for the alias %q of %q", tc.name, tc.expansion)
+		}
+	}
+}
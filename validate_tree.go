@@ -0,0 +1,242 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+// TreeProblemKind is the kind of mistake reported by ValidateTree.
+type TreeProblemKind string
+
+const (
+	// TreeProblemDuplicateCommand is reported when a name or alias is used by several sibling commands.
+	TreeProblemDuplicateCommand TreeProblemKind = "duplicate command"
+	// TreeProblemUndefinedGroup is reported when the GroupID of a command is not a group of its parent.
+	TreeProblemUndefinedGroup TreeProblemKind = "undefined group"
+	// TreeProblemShorthandCollision is reported when a flag has the shorthand of an inherited flag.
+	TreeProblemShorthandCollision TreeProblemKind = "shorthand collision"
+	// TreeProblemValidArgsConflict is reported when both ValidArgs and ValidArgsFunction are set.
+	TreeProblemValidArgsConflict TreeProblemKind = "valid args conflict"
+	// TreeProblemUndefinedFlag is reported when a flag group or requirement refers to a flag
+	// which is not defined.
+	TreeProblemUndefinedFlag TreeProblemKind = "undefined flag"
+	// TreeProblemHiddenFlagCompletion is reported when a completion function is registered for
+	// a hidden flag.
+	TreeProblemHiddenFlagCompletion TreeProblemKind = "hidden flag completion"
+	// TreeProblemMissingShort is reported when a command listed in the help of its parent has no Short.
+	TreeProblemMissingShort TreeProblemKind = "missing short"
+)
+
+// TreeProblem is a mistake in the definition of a tree of commands, reported by ValidateTree.
+type TreeProblem struct {
+	// Kind is the kind of the mistake.
+	Kind TreeProblemKind
+	// CommandPath is the path of the command with the mistake.
+	CommandPath string
+	// Flag is the name of the flag with the mistake, if any.
+	Flag string
+	// Message describes the mistake.
+	Message string
+}
+
+func (p TreeProblem) String() string {
+	return fmt.Sprintf("%s: %s", p.CommandPath, p.Message)
+}
+
+// flagGroupAnnotationKinds are the kinds of the flag groups stored in each annotation.
+var flagGroupAnnotationKinds = map[string]FlagGroupKind{
+	requiredAsGroupAnnotation:   FlagGroupRequiredTogether,
+	oneRequiredAnnotation:       FlagGroupOneRequired,
+	mutuallyExclusiveAnnotation: FlagGroupMutuallyExclusive,
+	exactlyOneAnnotation:        FlagGroupExactlyOne,
+}
+
+// ValidateTree checks c and all its subcommands for mistakes which are only detected when
+// a command is executed, or never, and returns all the problems found, or nil.  It is meant
+// to be called from a unit test of the program:
+//
+//	func TestCommands(t *testing.T) {
+//		for _, problem := range cmd.NewRootCmd().ValidateTree() {
+//			t.Error(problem)
+//		}
+//	}
+//
+// The lazy subcommands are built, see AddLazyCommand.
+func (c *Command) ValidateTree() []TreeProblem {
+	var problems []TreeProblem
+	report := func(cmd *Command, kind TreeProblemKind, flagName, format string, a ...interface{}) {
+		problems = append(problems, TreeProblem{
+			Kind:        kind,
+			CommandPath: cmd.CommandPath(),
+			Flag:        flagName,
+			Message:     fmt.Sprintf(format, a...),
+		})
+	}
+
+	var validate func(cmd *Command)
+	validate = func(cmd *Command) {
+		cmd.BuildLazyCommands()
+		if cmd.HasParent() {
+			cmd.validateSiblings(report)
+		}
+		if len(cmd.ValidArgs) > 0 && cmd.ValidArgsFunction != nil {
+			report(cmd, TreeProblemValidArgsConflict, "", "both ValidArgs and ValidArgsFunction are set")
+		}
+		if cmd.HasParent() && cmd.Short == "" && !cmd.Hidden && cmd.Deprecated == "" {
+			report(cmd, TreeProblemMissingShort, "", "the command has no short description")
+		}
+		cmd.validateFlags(report)
+
+		for _, sub := range cmd.Commands() {
+			validate(sub)
+		}
+	}
+	validate(c)
+	return problems
+}
+
+// validateSiblings reports the problems of c within the subcommands of its parent.
+func (c *Command) validateSiblings(report func(*Command, TreeProblemKind, string, string, ...interface{})) {
+	if c.GroupID != "" && !c.parent.ContainsGroup(c.GroupID) {
+		report(c, TreeProblemUndefinedGroup, "", "group id %q is not defined", c.GroupID)
+	}
+	for _, name := range append([]string{c.Name()}, c.Aliases...) {
+		// Only the siblings before c report the names they share with it
+		for _, sibling := range c.parent.commands {
+			if sibling == c {
+				break
+			}
+			if c.commandNameMatches(sibling.Name(), name) || sibling.HasAlias(name) {
+				report(c, TreeProblemDuplicateCommand, "", "%q is also the name or an alias of %q", name, sibling.CommandPath())
+				break
+			}
+		}
+	}
+}
+
+// validateFlags reports the problems of the flags defined by c.
+func (c *Command) validateFlags(report func(*Command, TreeProblemKind, string, string, ...interface{})) {
+	inherited := c.inheritedFlagsByName()
+	var own []*flag.Flag
+	addOwn := func(f *flag.Flag) {
+		if inherited[f.Name] != f {
+			own = append(own, f)
+		}
+	}
+	c.PersistentFlags().VisitAll(addOwn)
+	c.Flags().VisitAll(func(f *flag.Flag) {
+		if c.PersistentFlags().Lookup(f.Name) != f {
+			addOwn(f)
+		}
+	})
+
+	for _, f := range own {
+		if f.Shorthand != "" {
+			for _, other := range inherited {
+				if other.Shorthand == f.Shorthand && other.Name != f.Name {
+					report(c, TreeProblemShorthandCollision, f.Name, "the shorthand -%s of --%s is also the shorthand of the inherited flag --%s", f.Shorthand, f.Name, other.Name)
+					break
+				}
+			}
+		}
+		if _, ok := c.opts().flagCompletionFunc(f); ok && f.Hidden {
+			report(c, TreeProblemHiddenFlagCompletion, f.Name, "a completion function is registered for the hidden flag --%s", f.Name)
+		}
+
+		// The Mark* functions panic on undefined flags, but the annotations can also be set directly
+		for _, annotation := range sortedAnnotations(f.Annotations) {
+			kind, ok := flagGroupAnnotationKinds[annotation]
+			if !ok && annotation != requiresAnnotation {
+				continue
+			}
+			for _, group := range f.Annotations[annotation] {
+				names := strings.Split(group, " ")
+				description := fmt.Sprintf("the %s group [%s]", kind, group)
+				if annotation == requiresAnnotation {
+					var constraint flagConstraint
+					_ = json.Unmarshal([]byte(group), &constraint)
+					names = nil
+					for _, spec := range constraint.Requires {
+						names = append(names, parseFlagRequirement(spec).name)
+					}
+					description = fmt.Sprintf("a requirement of --%s", f.Name)
+				}
+				// A group marked on a subcommand can include the persistent flags of c
+				if c.definesFlagsInTree(names) {
+					continue
+				}
+				for _, name := range names {
+					if !c.definesFlag(name, inherited) {
+						report(c, TreeProblemUndefinedFlag, f.Name, "%s refers to the undefined flag --%s", description, name)
+					}
+				}
+			}
+		}
+	}
+}
+
+// inheritedFlagsByName returns the persistent flags of the parents of c, by name, without
+// merging them into the flags of c, which panics when their shorthands collide.
+func (c *Command) inheritedFlagsByName() map[string]*flag.Flag {
+	inherited := map[string]*flag.Flag{}
+	c.VisitParents(func(parent *Command) {
+		parent.PersistentFlags().VisitAll(func(f *flag.Flag) {
+			// The closest parent defines the flag
+			if _, ok := inherited[f.Name]; !ok {
+				inherited[f.Name] = f
+			}
+		})
+	})
+	return inherited
+}
+
+// definesFlag returns whether the named flag is defined by c or is one of its inherited flags.
+func (c *Command) definesFlag(name string, inherited map[string]*flag.Flag) bool {
+	return c.Flags().Lookup(name) != nil || c.PersistentFlags().Lookup(name) != nil || inherited[name] != nil
+}
+
+// definesFlagsInTree returns whether c or one of its subcommands defines all the named flags.
+func (c *Command) definesFlagsInTree(names []string) bool {
+	inherited := c.inheritedFlagsByName()
+	defined := true
+	for _, name := range names {
+		defined = defined && c.definesFlag(name, inherited)
+	}
+	if defined {
+		return true
+	}
+	for _, sub := range c.commands {
+		if sub.definesFlagsInTree(names) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedAnnotations returns the names of the annotations in alphabetical order.
+func sortedAnnotations(annotations map[string][]string) []string {
+	names := make([]string, 0, len(annotations))
+	for name := range annotations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
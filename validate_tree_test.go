@@ -0,0 +1,117 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateTreeValid(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "")
+	rootCmd.AddGroup(&Group{ID: "main", Title: "Main Commands:"})
+
+	childCmd := &Command{Use: "child", Aliases: []string{"c"}, Short: "child", GroupID: "main", ValidArgs: []string{"a"}, Run: emptyRun}
+	childCmd.Flags().Bool("json", false, "")
+	childCmd.Flags().Bool("yaml", false, "")
+	rootCmd.AddCommand(childCmd)
+	// The groups of the subcommands can include the persistent flags of their parents
+	childCmd.MarkFlagsMutuallyExclusive("json", "yaml", "verbose")
+	childCmd.MarkFlagRequires("yaml", "verbose")
+	rootCmd.AddCommand(&Command{Use: "hidden", Hidden: true, Run: emptyRun})
+	// An empty ValidArgs does not conflict with ValidArgsFunction
+	rootCmd.AddCommand(&Command{Use: "dynamic", Short: "dynamic", ValidArgs: []string{}, ValidArgsFunction: NoFileCompletions, Run: emptyRun})
+	rootCmd.AddLazyCommand(&Command{Use: "lazy", Short: "lazy"}, func() *Command {
+		return &Command{Use: "lazy", Short: "lazy", Run: emptyRun}
+	})
+
+	// Executing the tree merges the persistent flags and adds the help flags
+	if _, err := executeCommand(rootCmd, "child", "--json"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if problems := rootCmd.ValidateTree(); problems != nil {
+		t.Errorf("Expected no problem, got %v", problems)
+	}
+}
+
+func TestValidateTree(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "")
+
+	childCmd := &Command{
+		Use:       "child",
+		Short:     "child",
+		ValidArgs: []string{"a"},
+		ValidArgsFunction: func(cmd *Command, args []string, toComplete string) ([]Completion, ShellCompDirective) {
+			return nil, ShellCompDirectiveDefault
+		},
+		Run: emptyRun,
+	}
+	childCmd.Flags().BoolP("version", "v", false, "")
+	childCmd.Flags().String("token", "", "")
+	assertNoErr(t, childCmd.Flags().MarkHidden("token"))
+	assertNoErr(t, childCmd.RegisterFlagCompletionFunc("token", NoFileCompletions))
+	assertNoErr(t, childCmd.Flags().SetAnnotation("token", mutuallyExclusiveAnnotation, []string{"token password"}))
+	assertNoErr(t, childCmd.Flags().SetAnnotation("token", requiresAnnotation, []string{`{"condition":"*","requires":["user=me"]}`}))
+	rootCmd.AddCommand(childCmd)
+
+	rootCmd.AddCommand(&Command{Use: "other", Aliases: []string{"child"}, GroupID: "missing", Run: emptyRun})
+	rootCmd.AddLazyCommand(&Command{Use: "lazy", Short: "lazy"}, func() *Command {
+		return &Command{Use: "lazy", Run: emptyRun}
+	})
+
+	expected := []TreeProblem{
+		{TreeProblemValidArgsConflict, "root child", "", "both ValidArgs and ValidArgsFunction are set"},
+		{TreeProblemHiddenFlagCompletion, "root child", "token", "a completion function is registered for the hidden flag --token"},
+		{TreeProblemUndefinedFlag, "root child", "token", "the mutually exclusive group [token password] refers to the undefined flag --password"},
+		{TreeProblemUndefinedFlag, "root child", "token", "a requirement of --token refers to the undefined flag --user"},
+		{TreeProblemShorthandCollision, "root child", "version", "the shorthand -v of --version is also the shorthand of the inherited flag --verbose"},
+		{TreeProblemMissingShort, "root lazy", "", "the command has no short description"},
+		{TreeProblemUndefinedGroup, "root other", "", `group id "missing" is not defined`},
+		{TreeProblemDuplicateCommand, "root other", "", `"child" is also the name or an alias of "root child"`},
+		{TreeProblemMissingShort, "root other", "", "the command has no short description"},
+	}
+	problems := rootCmd.ValidateTree()
+	if !reflect.DeepEqual(problems, expected) {
+		t.Errorf("Expected problems:\n%v\nGot:\n%v", expected, problems)
+	}
+}
+
+func TestValidateTreeUndefinedFlagGroups(t *testing.T) {
+	rootCmd := &Command{Use: "root", Run: emptyRun}
+	rootCmd.PersistentFlags().String("region", "", "")
+	rootCmd.Flags().String("a", "", "")
+	assertNoErr(t, rootCmd.Flags().SetAnnotation("a", requiredAsGroupAnnotation, []string{"a b"}))
+	assertNoErr(t, rootCmd.Flags().SetAnnotation("a", oneRequiredAnnotation, []string{"a c"}))
+	assertNoErr(t, rootCmd.Flags().SetAnnotation("a", exactlyOneAnnotation, []string{"a region"}))
+	assertNoErr(t, rootCmd.Flags().SetAnnotation("a", requiresAnnotation, []string{`{"condition":"x","requires":["region","d=1"]}`}))
+
+	childCmd := &Command{Use: "child", Short: "child", Run: emptyRun}
+	childCmd.Flags().String("zone", "", "")
+	rootCmd.AddCommand(childCmd)
+	// The group is defined by the subcommand, which inherits --region
+	assertNoErr(t, rootCmd.PersistentFlags().SetAnnotation("region", mutuallyExclusiveAnnotation, []string{"region zone"}))
+
+	expected := []TreeProblem{
+		{TreeProblemUndefinedFlag, "root", "a", "the one required group [a c] refers to the undefined flag --c"},
+		{TreeProblemUndefinedFlag, "root", "a", "the required together group [a b] refers to the undefined flag --b"},
+		{TreeProblemUndefinedFlag, "root", "a", "a requirement of --a refers to the undefined flag --d"},
+	}
+	problems := rootCmd.ValidateTree()
+	if !reflect.DeepEqual(problems, expected) {
+		t.Errorf("Expected problems:\n%v\nGot:\n%v", expected, problems)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2013-2023 The Cobra Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cobra
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func genXonshComp(buf io.StringWriter, name string, includeDesc bool) {
+	// Python identifiers should not contain a '-', ':' or '.' character
+	nameForVar := name
+	nameForVar = strings.ReplaceAll(nameForVar, "-", "_")
+	nameForVar = strings.ReplaceAll(nameForVar, ":", "_")
+	nameForVar = strings.ReplaceAll(nameForVar, ".", "_")
+
+	compCmd := ShellCompRequestCmd
+	if !includeDesc {
+		compCmd = ShellCompNoDescRequestCmd
+	}
+	WriteStringAndCheck(buf, fmt.Sprintf("# xonsh completion for %-36s -*- shell-script -*-\n", name))
+	WriteStringAndCheck(buf, fmt.Sprintf(`
+import os
+import subprocess
+
+from xonsh.completers.completer import add_one_completer
+from xonsh.completers.tools import RichCompletion, contextual_command_completer
+
+
+def _%[1]s_debug(msg):
+    path = os.environ.get("BASH_COMP_DEBUG_FILE")
+    if path:
+        with open(path, "a") as f:
+            f.write(msg + "\n")
+
+
+def _%[1]s_list_files(prefix, exts=None, dirs_only=False, within=""):
+    base = os.path.join(within, prefix) if within else prefix
+    directory, partial = os.path.split(base)
+    try:
+        entries = os.listdir(directory or ".")
+    except OSError:
+        return []
+    files = []
+    for entry in sorted(entries):
+        if not entry.startswith(partial):
+            continue
+        if entry.startswith(".") and not partial.startswith("."):
+            continue
+        full = os.path.join(directory, entry)
+        value = full[len(within) + 1:] if within else full
+        if os.path.isdir(full):
+            files.append(RichCompletion(value + os.sep, append_space=False))
+        elif not dirs_only and (exts is None or any(entry.endswith("." + ext) for ext in exts)):
+            files.append(RichCompletion(value, append_space=True))
+    return files
+
+
+@contextual_command_completer
+def _%[1]s_completer(context):
+    """Obtain the completion choices for %[2]s from the program itself."""
+    if not context.completing_command("%[2]s"):
+        return None
+
+    shellCompDirectiveError = %[4]d
+    shellCompDirectiveNoSpace = %[5]d
+    shellCompDirectiveNoFileComp = %[6]d
+    shellCompDirectiveFilterFileExt = %[7]d
+    shellCompDirectiveFilterDirs = %[8]d
+    shellCompDirectiveKeepOrder = %[9]d
+
+    prog = context.args[0].value
+    args = [arg.value for arg in context.args[1:context.arg_index]]
+    lastArg = context.prefix
+
+    # When completing a flag with an = (e.g., <program> -n=<TAB>)
+    # completions must be prefixed with the flag
+    flagPrefix = ""
+    if lastArg.startswith("-") and "=" in lastArg:
+        flagPrefix = lastArg[:lastArg.index("=") + 1]
+
+    requestComp = [prog, "%[3]s"] + args + [lastArg]
+    _%[1]s_debug("Calling " + " ".join(requestComp))
+    try:
+        out = subprocess.run(requestComp, stdout=subprocess.PIPE, stderr=subprocess.DEVNULL, text=True).stdout
+    except OSError:
+        return None
+
+    # Some programs may output extra empty lines after the directive.
+    # Let's ignore them or else it will break completion.
+    # Ref: https://github.com/spf13/cobra/issues/1279
+    lines = out.rstrip().splitlines()
+    if not lines or not lines[-1].startswith(":"):
+        # No completions, probably due to a failure; let xonsh do file completion
+        return None
+
+    # The directive is the last line and has the format :<directive>
+    directive = int(lines[-1][1:])
+    comps = lines[:-1]
+    _%[1]s_debug("Directive is: " + str(directive))
+
+    if directive & shellCompDirectiveError:
+        # Might as well do file completion, in case it helps
+        return None
+
+    # ActiveHelp messages are shown as choices which leave the command-line unchanged
+    activeHelp = [
+        RichCompletion(lastArg, display=c[len("%[10]s"):], append_space=False)
+        for c in comps if c.startswith("%[10]s")
+    ]
+    comps = [c for c in comps if not c.startswith("%[10]s")]
+
+    if directive & shellCompDirectiveFilterFileExt:
+        # The completions are the file extensions to keep
+        return set(_%[1]s_list_files(lastArg, exts=comps) + activeHelp)
+
+    if directive & shellCompDirectiveFilterDirs:
+        # If a completion is provided, it is the directory within which to search
+        within = comps[0] if comps else ""
+        return set(_%[1]s_list_files(lastArg, dirs_only=True, within=within) + activeHelp)
+
+    results = []
+    for comp in comps:
+        value, _, description = comp.partition("\t")
+        results.append(RichCompletion(
+            flagPrefix + value,
+            description=description,
+            append_space=not (directive & shellCompDirectiveNoSpace),
+        ))
+    results += activeHelp
+
+    if not results:
+        if directive & shellCompDirectiveNoFileComp:
+            # Prevent the other completers, such as file completion, from running
+            raise StopIteration
+        # To be consistent with other shells, we only trigger file
+        # completion when there are no other completions
+        return None
+
+    if directive & shellCompDirectiveKeepOrder:
+        return results
+    return set(results)
+
+
+add_one_completer("%[2]s", _%[1]s_completer, "start")
+`, nameForVar, name, compCmd,
+		ShellCompDirectiveError, ShellCompDirectiveNoSpace, ShellCompDirectiveNoFileComp,
+		ShellCompDirectiveFilterFileExt, ShellCompDirectiveFilterDirs, ShellCompDirectiveKeepOrder,
+		activeHelpMarker))
+}
+
+// GenXonshCompletion generates xonsh completion file and writes to the passed writer.
+func (c *Command) GenXonshCompletion(w io.Writer, includeDesc bool) error {
+	buf := new(bytes.Buffer)
+	genXonshComp(buf, c.Name(), includeDesc)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// GenXonshCompletionFile generates xonsh completion file.
+func (c *Command) GenXonshCompletionFile(filename string, includeDesc bool) error {
+	outFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	return c.GenXonshCompletion(outFile, includeDesc)
+}
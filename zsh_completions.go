@@ -47,9 +47,9 @@ func (c *Command) GenZshCompletionNoDesc(w io.Writer) error {
 // not consistent with Bash completion. It has therefore been disabled.
 // Instead, when no other completion is specified, file completion is done by
 // default for every argument. One can disable file completion on a per-argument
-// basis by using ValidArgsFunction and ShellCompDirectiveNoFileComp.
-// To achieve file extension filtering, one can use ValidArgsFunction and
-// ShellCompDirectiveFilterFileExt.
+// basis by using RegisterArgCompletionFunc and NoFileCompletions.
+// To achieve file extension filtering, one can use RegisterArgCompletionFunc and
+// FileCompletions.
 //
 // Deprecated
 func (c *Command) MarkZshCompPositionalArgumentFile(argPosition int, patterns ...string) error {
@@ -59,8 +59,7 @@ func (c *Command) MarkZshCompPositionalArgumentFile(argPosition int, patterns ..
 // MarkZshCompPositionalArgumentWords only worked for zsh. It has therefore
 // been disabled.
 // To achieve the same behavior across all shells, one can use
-// ValidArgs (for the first argument only) or ValidArgsFunction for
-// any argument (can include the first one also).
+// RegisterArgCompletionFunc and FixedCompletions for any argument.
 //
 // Deprecated
 func (c *Command) MarkZshCompPositionalArgumentWords(argPosition int, words ...string) error {